@@ -21,6 +21,7 @@ type ShowUser struct {
 	DelayTimes     uint    //使用延时次数
 	AutoCheckTimes uint    //自动Check次数
 	AutoFoldTimes  uint    //自动Fold次数
+	Bounty         uint    //身上的赏金
 }
 
 type Agent struct {
@@ -33,8 +34,10 @@ type Agent struct {
 	addTime           time.Duration
 	betCh             chan *Bet
 	insuranceCh       chan []*BuyInsurance
+	runCh             chan int8
 	atomBetLock       int32
 	atomInsuranceLock int32
+	atomRunLock       int32
 	showUser          *ShowUser
 	nextAgent         *Agent
 	prevAgent         *Agent
@@ -156,11 +159,8 @@ func (c *Agent) Leave(holdem *Holdem) {
 	if c.h == nil {
 		return
 	}
-	if c.gameInfo == nil {
-		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
-		return
-	}
-	if c.gameInfo.seatNumber > 0 {
+	//旁观或者已经站起的可以直接离开
+	if c.gameInfo != nil && c.gameInfo.seatNumber > 0 {
 		c.recv.ErrorOccur(c.h.id, ErrCodeNotStandUp, errNotStandUp)
 		return
 	}
@@ -182,17 +182,7 @@ func (c *Agent) BringIn(chip uint) {
 		c.recv.ErrorOccur(c.h.id, ErrCodeLessChip, errLessChip)
 		return
 	}
-	if c.gameInfo != nil {
-		c.gameInfo.bringIn += chip
-		c.gameInfo.chip += chip
-	} else {
-		c.gameInfo = &gameInfo{
-			chip:    chip,
-			bringIn: chip,
-		}
-	}
-	c.log.Debug("user bring in", zap.Int8("seat", c.gameInfo.seatNumber), zap.String("id", c.id), zap.Uint("bringin", chip))
-	c.recv.PlayerBringInSuccess(c.h.id, c.gameInfo.seatNumber, c.id, chip)
+	c.h.bringIn(c, chip)
 }
 
 //Seated 坐下（不输入座位号,自动寻座)
@@ -248,6 +238,43 @@ func (c *Agent) Bet(bet *Bet) {
 	c.recv.ErrorOccur(c.h.id, ErrCodeNotInBetTime, errNotInBetTime)
 }
 
+//Straddle 下一手抓头(需要在发牌前)
+func (c *Agent) Straddle() {
+	if c.h == nil {
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	if c.h.options.maxStraddles <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeCannotStraddle, errCannotStraddle)
+		return
+	}
+	c.gameInfo.straddle = true
+	c.recv.PlayerStraddleSuccess(c.h.id, c.gameInfo.seatNumber, c.id)
+}
+
+//AutoTopUp 两手之间自动补码到目标筹码(大盲数,0为关闭,不超过最大带入)
+func (c *Agent) AutoTopUp(target uint) {
+	if c.h == nil {
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	c.gameInfo.autoTopUp = target
+}
+
 //PayToPlay 补盲
 func (c *Agent) PayToPlay() {
 	if c.gameInfo == nil {
@@ -277,6 +304,107 @@ func (c *Agent) BuyInsurance(insurance []*BuyInsurance) {
 	c.recv.ErrorOccur(c.h.id, ErrCodeNotInBetTime, errNotInBetTime)
 }
 
+//RabbitHunt 提前结束的一手看剩余的公共牌(下一手开始前)
+func (c *Agent) RabbitHunt() {
+	if c.h == nil {
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	c.h.rabbitHunt(c)
+}
+
+//ClientSeed 可验证公平模式提供自己的种子(只用于已经公布承诺的下一手,每手都要在收到承诺后重新提供)
+func (c *Agent) ClientSeed(seed string) {
+	if c.h == nil {
+		return
+	}
+	if !c.h.options.provablyFair {
+		c.recv.ErrorOccur(c.h.id, ErrCodeFairNotEnabled, errFairNotEnabled)
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	c.h.seatLock.Lock()
+	defer c.h.seatLock.Unlock()
+	//这一手发牌后到公布下一手的承诺之前不接受
+	if c.h.fairNext == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeFairNoCommitment, errFairNoCommitment)
+		return
+	}
+	c.gameInfo.clientSeed = seed
+	c.gameInfo.clientSeedHand = c.h.fairNext.handNum
+}
+
+//Rebuy 重购(筹码下一手开始前加上)
+func (c *Agent) Rebuy() {
+	if c.h == nil {
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	c.h.rebuyChip(c)
+}
+
+//AddOn 加码(休息时,筹码下一手开始前加上)
+func (c *Agent) AddOn() {
+	if c.h == nil {
+		return
+	}
+	if c.gameInfo == nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNotPlaying, errNotPlaying)
+		return
+	}
+	if c.gameInfo.seatNumber <= 0 {
+		c.recv.ErrorOccur(c.h.id, ErrCodeNoSeat, errNoSeat)
+		return
+	}
+	c.h.addOn(c)
+}
+
+//ReEnter 被淘汰后重新参赛(成功后需要重新坐下)
+func (c *Agent) ReEnter() {
+	if c.h == nil {
+		return
+	}
+	if c.h.status() == GameStatusComplete || c.h.status() == GameStatusCancel {
+		c.recv.ErrorOccur(c.h.id, ErrCodeGameOver, errGameOver)
+		return
+	}
+	if c.gameInfo != nil {
+		c.recv.ErrorOccur(c.h.id, ErrCodeAlreadySeated, errAlreadySeated)
+		return
+	}
+	c.h.reEnter(c)
+}
+
+//RunMultiple 全下后选择发几次牌(1为只发一次)
+func (c *Agent) RunMultiple(times int8) {
+	if c.canRunMultiple() {
+		c.runCh <- times
+		return
+	}
+	c.recv.ErrorOccur(c.h.id, ErrCodeNotInBetTime, errNotInBetTime)
+}
+
 //ShowUser 展示用户信息
 func (c *Agent) displayUser(showCards bool) *ShowUser {
 	if c.gameInfo == nil {
@@ -301,6 +429,9 @@ func (c *Agent) displayUser(showCards bool) *ShowUser {
 	c.showUser.AutoCheckTimes = c.gameInfo.autoCheckTimes
 	c.showUser.AutoFoldTimes = c.gameInfo.autoFoldTimes
 	c.showUser.DelayTimes = c.gameInfo.delayTimes
+	if c.h != nil && c.h.bounty != nil {
+		c.showUser.Bounty = c.h.bounty.get(c.id)
+	}
 	if showCards {
 		c.showUser.Cards = c.gameInfo.cards
 	}
@@ -406,6 +537,57 @@ func (c *Agent) waitBuyInsurance(outsLen int, odds float64, outs map[int8][]*Use
 	}
 }
 
+func (c *Agent) canRunMultiple() bool {
+	return atomic.LoadInt32(&c.atomRunLock) == 1
+}
+
+func (c *Agent) enableRunMultiple(enable bool) {
+	if enable {
+		if atomic.LoadInt32(&c.atomRunLock) == 0 {
+			atomic.AddInt32(&c.atomRunLock, 1)
+		}
+		c.runCh = make(chan int8, 1)
+		return
+	}
+	if atomic.LoadInt32(&c.atomRunLock) == 1 {
+		atomic.AddInt32(&c.atomRunLock, -1)
+		close(c.runCh)
+	}
+}
+
+//waitRunTimes 等待玩家选择发牌次数(超时或托管只发一次)
+func (c *Agent) waitRunTimes(max int8, timeout time.Duration) int8 {
+	var times int8 = 1
+	defer func() {
+		c.log.Debug("run multiple vote end", zap.Int8("seat", c.gameInfo.seatNumber), zap.Int8("times", times))
+	}()
+	if c.auto {
+		return times
+	}
+	c.enableRunMultiple(true)
+	timer := time.NewTimer(timeout)
+	defer func() {
+		c.enableRunMultiple(false)
+		timer.Stop()
+	}()
+	for {
+		select {
+		case t, ok := <-c.runCh:
+			if !ok {
+				return times
+			}
+			if t < 1 || t > max {
+				c.recv.ErrorOccur(c.h.id, ErrCodeInvalidRunTimes, errInvalidRunTimes)
+				continue
+			}
+			times = t
+			return times
+		case <-timer.C:
+			return times
+		}
+	}
+}
+
 type betSort []*Agent
 
 func (p betSort) Len() int { return len(p) }
@@ -447,10 +629,10 @@ type BetGroup struct {
 // 	return pots
 // }
 
-func (c *Agent) waitBet(curBet uint, minRaise uint, round Round, timeout time.Duration) (rbet *Bet) {
+func (c *Agent) waitBet(s *BetSituation, timeout time.Duration) (rbet *Bet) {
 	defer func() {
 		c.enableBet(false)
-		c.log.Debug("bet end", zap.Int8("seat", c.gameInfo.seatNumber), zap.String("status", c.gameInfo.status.String()), zap.Uint("amount", rbet.Num), zap.Bool("auto", rbet.Auto), zap.String("round", round.String()))
+		c.log.Debug("bet end", zap.Int8("seat", c.gameInfo.seatNumber), zap.String("status", c.gameInfo.status.String()), zap.Uint("amount", rbet.Num), zap.Bool("auto", rbet.Auto), zap.String("round", s.Round.String()))
 	}()
 	//托管直接操作
 	if c.auto {
@@ -462,7 +644,7 @@ func (c *Agent) waitBet(curBet uint, minRaise uint, round Round, timeout time.Du
 			Auto:   true,
 		}
 		//无法check就fold
-		if valid, _ := c.isValidBet(rbet, curBet, minRaise, round); !valid {
+		if valid, _ := c.isValidBet(rbet, s); !valid {
 			rbet.Action = ActionDefFold
 			c.gameInfo.status = ActionDefFold
 		}
@@ -485,15 +667,16 @@ func (c *Agent) waitBet(curBet uint, minRaise uint, round Round, timeout time.Du
 			if c.auto {
 				c.h.autoOp(c, false)
 			}
-			if valid, err2 := c.isValidBet(bet, curBet, minRaise, round); valid {
+			if valid, err2 := c.isValidBet(bet, s); valid {
 				c.gameInfo.status = bet.Action
 				c.gameInfo.handBet += bet.Num
 				c.gameInfo.roundBet += bet.Num
 				c.gameInfo.chip -= bet.Num
+				c.h.transferChip(accountPot, playerAccount(c.id), bet.Num, bet.Action.String())
 				rbet = bet
 				return
 			} else {
-				c.log.Error("invalid bet num", zap.String("action", bet.Action.String()), zap.Uint("num", bet.Num), zap.Uint("maxbet", curBet), zap.Uint("mybeted", c.gameInfo.roundBet), zap.Uint("min_raise", minRaise), zap.Uint("mychip", c.gameInfo.chip))
+				c.log.Error("invalid bet num", zap.String("action", bet.Action.String()), zap.Uint("num", bet.Num), zap.Uint("maxbet", s.TableBet), zap.Uint("mybeted", c.gameInfo.roundBet), zap.Uint("min_raise", s.MinRaise), zap.Uint("mychip", c.gameInfo.chip))
 				c.recv.ErrorOccur(c.h.id, err2.code, err2.err)
 			}
 		case <-timer.C:
@@ -513,7 +696,7 @@ func (c *Agent) waitBet(curBet uint, minRaise uint, round Round, timeout time.Du
 				Auto:   true,
 			}
 			//无法check就fold
-			if valid, _ := c.isValidBet(rbet, curBet, minRaise, round); !valid {
+			if valid, _ := c.isValidBet(rbet, s); !valid {
 				rbet.Action = ActionDefFold
 				c.gameInfo.status = ActionDefFold
 				c.gameInfo.autoFoldTimes++
@@ -531,35 +714,37 @@ func (c *Agent) waitBet(curBet uint, minRaise uint, round Round, timeout time.Du
 }
 
 //isValidBet 判断是否是有效的投注
-func (c *Agent) isValidBet(bet *Bet, maxRoundBet uint, minRaise uint, round Round) (bool, *errorWithCode) {
-	//第一个人/或者前面没有人下注
+func (c *Agent) isValidBet(bet *Bet, s *BetSituation) (bool, *errorWithCode) {
 	actions := make(map[ActionDef]uint)
-	if maxRoundBet == 0 {
-		if c.gameInfo.chip > minRaise {
-			actions[ActionDefFold] = 0
-			actions[ActionDefBet] = minRaise
-			actions[ActionDefCheck] = 0
-			actions[ActionDefAllIn] = c.gameInfo.chip
-		} else {
-			actions[ActionDefFold] = 0
-			actions[ActionDefCheck] = 0
-			actions[ActionDefAllIn] = c.gameInfo.chip
+	call := s.Call()
+	//下注结构决定下注/加注的范围
+	min, max, canRaise := c.h.options.betting.Limit(s)
+	if max > s.Chip {
+		max = s.Chip
+	}
+	actions[ActionDefFold] = 0
+	//第一个人/或者前面没有人下注
+	if s.TableBet == 0 {
+		actions[ActionDefCheck] = 0
+		if canRaise && s.Chip > min {
+			actions[ActionDefBet] = min
 		}
 	} else {
+		//已跟上(大盲/抓头的option)可以check
+		if call == 0 {
+			actions[ActionDefCheck] = 0
+		}
 		//筹码大于当前下注
-		if c.gameInfo.chip > maxRoundBet-c.gameInfo.roundBet+minRaise {
-			actions[ActionDefFold] = 0
-			actions[ActionDefCall] = maxRoundBet - c.gameInfo.roundBet
-			actions[ActionDefRaise] = maxRoundBet - c.gameInfo.roundBet + minRaise
-			actions[ActionDefAllIn] = c.gameInfo.chip
-		} else if c.gameInfo.chip > maxRoundBet-c.gameInfo.roundBet {
-			actions[ActionDefFold] = 0
-			actions[ActionDefCall] = maxRoundBet - c.gameInfo.roundBet
-			actions[ActionDefAllIn] = c.gameInfo.chip
-		} else {
-			actions[ActionDefFold] = 0
-			actions[ActionDefAllIn] = c.gameInfo.chip
+		if s.Chip > call {
+			actions[ActionDefCall] = call
 		}
+		if canRaise && s.Chip > min {
+			actions[ActionDefRaise] = min
+		}
+	}
+	//超过限注的全下不允许(筹码不足跟注时总是可以全下)
+	if s.Chip <= call || (canRaise && s.Chip <= max) {
+		actions[ActionDefAllIn] = s.Chip
 	}
 	amount, ok := actions[bet.Action]
 	if !ok {
@@ -568,8 +753,7 @@ func (c *Agent) isValidBet(bet *Bet, maxRoundBet uint, minRaise uint, round Roun
 			err:  errInvalidBetAction,
 		}
 	}
-	if (bet.Action == ActionDefRaise && bet.Num < amount) ||
-		(bet.Action == ActionDefBet && bet.Num < amount) ||
+	if ((bet.Action == ActionDefRaise || bet.Action == ActionDefBet) && (bet.Num < amount || bet.Num > max)) ||
 		(bet.Action != ActionDefRaise && bet.Action != ActionDefBet && bet.Num != amount) {
 		return false, &errorWithCode{
 			code: ErrCodeInvalidBetNum,
@@ -0,0 +1,113 @@
+package holdem
+
+//BetSituation 当前下注情况(下注结构据此计算下注范围)
+type BetSituation struct {
+	//Round 回合
+	Round Round
+	//Chip 手上筹码
+	Chip uint
+	//RoundBet 本轮已下注
+	RoundBet uint
+	//TableBet 本轮桌上最高下注
+	TableBet uint
+	//MinRaise 最小加注量
+	MinRaise uint
+	//Pot 底池(包括本轮已下注)
+	Pot uint
+	//BigBlind 大盲
+	BigBlind uint
+	//RaiseTimes 本轮下注/加注次数(翻牌前大盲算一次)
+	RaiseTimes uint
+}
+
+//Call 跟注需要投入
+func (c *BetSituation) Call() uint {
+	return c.TableBet - c.RoundBet
+}
+
+//BettingStructure 下注结构(无限注/底池限注/固定限注)
+type BettingStructure interface {
+	//Limit 下注/加注本次投入的最小值和最大值(可能超过手上筹码),ok为false表示不能再下注/加注
+	Limit(s *BetSituation) (min uint, max uint, ok bool)
+}
+
+//NoLimit 无限注
+type NoLimit struct {
+}
+
+var _ BettingStructure = (*NoLimit)(nil)
+
+func (c *NoLimit) Limit(s *BetSituation) (uint, uint, bool) {
+	return s.Call() + s.MinRaise, s.Chip, true
+}
+
+//PotLimit 底池限注(最多加注到跟注后的底池大小)
+type PotLimit struct {
+}
+
+var _ BettingStructure = (*PotLimit)(nil)
+
+func (c *PotLimit) Limit(s *BetSituation) (uint, uint, bool) {
+	call := s.Call()
+	return call + s.MinRaise, call + s.Pot + call, true
+}
+
+//FixedLimit 固定限注
+type FixedLimit struct {
+	//Sizes 每轮下注/加注额度(不设置时翻牌前/翻牌为大盲,转牌/河牌为2倍大盲)
+	Sizes map[Round]uint
+	//RaiseCap 每轮下注+加注的最多次数(0为4次)
+	RaiseCap uint
+}
+
+var _ BettingStructure = (*FixedLimit)(nil)
+
+//NewFixedLimit 固定限注(小注用于翻牌前/翻牌,大注用于转牌/河牌,为0时使用默认值)
+func NewFixedLimit(smallBet uint, bigBet uint, raiseCap uint) *FixedLimit {
+	sizes := make(map[Round]uint)
+	if smallBet > 0 {
+		sizes[RoundPreFlop] = smallBet
+		sizes[RoundFlop] = smallBet
+	}
+	if bigBet > 0 {
+		sizes[RoundTurn] = bigBet
+		sizes[RoundRiver] = bigBet
+	}
+	return &FixedLimit{
+		Sizes:    sizes,
+		RaiseCap: raiseCap,
+	}
+}
+
+func (c *FixedLimit) Limit(s *BetSituation) (uint, uint, bool) {
+	raiseCap := c.RaiseCap
+	if raiseCap == 0 {
+		raiseCap = 4
+	}
+	if s.RaiseTimes >= raiseCap {
+		return 0, 0, false
+	}
+	size, ok := c.Sizes[s.Round]
+	if !ok {
+		size = s.BigBlind
+		if s.Round == RoundTurn || s.Round == RoundRiver {
+			size = 2 * s.BigBlind
+		}
+	}
+	put := s.Call() + size
+	return put, put, true
+}
+
+//betSituation 当前玩家的下注情况
+func (c *Holdem) betSituation(r *Agent, round Round) *BetSituation {
+	return &BetSituation{
+		Round:      round,
+		Chip:       r.gameInfo.chip,
+		RoundBet:   r.gameInfo.roundBet,
+		TableBet:   c.roundBet,
+		MinRaise:   c.minRaise,
+		Pot:        c.pot,
+		BigBlind:   c.bb,
+		RaiseTimes: c.raiseTimes,
+	}
+}
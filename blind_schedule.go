@@ -0,0 +1,133 @@
+package holdem
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//BlindLevel 盲注级别(按手数或者时间升级,都为0时一直持续)
+type BlindLevel struct {
+	SmallBlind uint
+	//BigBlind 大盲(0时为小盲的2倍)
+	BigBlind uint
+	Ante     uint
+	//Hands 持续手数
+	Hands uint
+	//Duration 持续时间
+	Duration time.Duration
+	//Break 休息(暂停Duration时间,盲注沿用上一级)
+	Break bool
+}
+
+//bigBlind 实际大盲
+func (c *BlindLevel) bigBlind() uint {
+	if c.BigBlind > 0 {
+		return c.BigBlind
+	}
+	return c.SmallBlind * 2
+}
+
+//BlindSchedule 盲注表(按顺序升级,最后一级一直持续)
+type BlindSchedule []*BlindLevel
+
+//blindState 盲注表进度
+type blindState struct {
+	schedule   BlindSchedule
+	level      int
+	levelHands uint
+	levelStart time.Time
+}
+
+//expired 当前级别是否已经结束(最后一级不会结束,休息结束后立刻升级)
+func (c *blindState) expired() bool {
+	if c.level+1 >= len(c.schedule) {
+		return false
+	}
+	lv := c.schedule[c.level]
+	if lv.Break {
+		return true
+	}
+	return (lv.Hands > 0 && c.levelHands >= lv.Hands) || (lv.Duration > 0 && time.Since(c.levelStart) >= lv.Duration)
+}
+
+//current 当前生效的盲注级别(休息时为上一个非休息级别)
+func (c *blindState) current() *BlindLevel {
+	for i := c.level; i >= 0; i-- {
+		if !c.schedule[i].Break {
+			return c.schedule[i]
+		}
+	}
+	return nil
+}
+
+//nextBlindLevel 每手开始前按盲注表升级,遇到休息时暂停到休息结束
+func (c *Holdem) nextBlindLevel() {
+	s := c.blinds
+	if s.levelStart.IsZero() {
+		s.levelStart = time.Now()
+		if s.schedule[0].Break {
+			c.takeBreak()
+		}
+	}
+	for s.expired() {
+		s.level++
+		s.levelHands = 0
+		s.levelStart = time.Now()
+		lv := s.schedule[s.level]
+		if lv.Break {
+			c.takeBreak()
+			continue
+		}
+		c.log.Debug("blind level up", zap.Int("level", s.level), zap.Uint("sb", lv.SmallBlind), zap.Uint("bb", lv.bigBlind()), zap.Uint("ante", lv.Ante))
+	}
+}
+
+//applyBlindLevel 应用当前级别的盲注并广播倒计时
+func (c *Holdem) applyBlindLevel() {
+	s := c.blinds
+	s.levelHands++
+	if lv := s.current(); lv != nil {
+		c.sb = lv.SmallBlind
+		c.bb = lv.bigBlind()
+		c.ante = lv.Ante
+	}
+	c.blindCountdown()
+}
+
+//takeBreak 休息(暂停到休息时间结束,期间可以提前继续)
+func (c *Holdem) takeBreak() {
+	lv := c.blinds.schedule[c.blinds.level]
+	c.log.Debug("blind break", zap.Int("level", c.blinds.level), zap.Duration("duration", lv.Duration))
+	c.blindCountdown()
+	c.Pause()
+	t := time.AfterFunc(lv.Duration, c.Resume)
+	c.waitPause()
+	t.Stop()
+}
+
+//blindCountdown 广播当前级别和距离下一级别的剩余手数/时间
+func (c *Holdem) blindCountdown() {
+	s := c.blinds
+	lv := s.schedule[s.level]
+	var next *BlindLevel
+	if s.level+1 < len(s.schedule) {
+		next = s.schedule[s.level+1]
+	}
+	var hands uint
+	if lv.Hands > s.levelHands {
+		hands = lv.Hands - s.levelHands
+	}
+	var dur time.Duration
+	if lv.Duration > 0 {
+		dur = lv.Duration - time.Since(s.levelStart)
+		if dur < 0 {
+			dur = 0
+		}
+	}
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	for _, rr := range c.roomers {
+		rr.recv.RoomerGetBlindLevel(c.id, s.level, lv, next, hands, dur)
+	}
+}
@@ -0,0 +1,58 @@
+package holdem
+
+import "go.uber.org/zap"
+
+//BombPot 炸弹底池(所有人下固定数量,没有翻牌前下注)
+type BombPot struct {
+	//Amount 每人下注数量
+	Amount uint
+	//DoubleBoard 双公共牌(每个池两组公共牌平分)
+	DoubleBoard bool
+}
+
+//NextBombPot 下一手为炸弹底池(可以在nextGame回调中调用)
+func (c *Holdem) NextBombPot(amount uint, doubleBoard bool) {
+	c.nextBombPot = &BombPot{
+		Amount:      amount,
+		DoubleBoard: doubleBoard,
+	}
+}
+
+//doubleBoard 本手是否是双公共牌
+func (c *Holdem) doubleBoard() bool {
+	return c.bombPot != nil && c.bombPot.DoubleBoard
+}
+
+//bombPotUsers 炸弹底池下注后未盖牌的玩家,是否直接亮牌
+func (c *Holdem) bombPotUsers() ([]*Agent, bool) {
+	users := make([]*Agent, 0)
+	allInCount := 0
+	u := c.button
+	for {
+		if !u.fake {
+			users = append(users, u)
+			if u.gameInfo.status == ActionDefAllIn {
+				allInCount++
+			}
+		}
+		u = u.nextAgent
+		if u == c.button {
+			break
+		}
+	}
+	return users, allInCount > 0 && len(users) > 1 && allInCount >= len(users)-1
+}
+
+//dealSecondBoard 双公共牌时发第二组公共牌
+func (c *Holdem) dealSecondBoard(n int) {
+	//洗牌
+	_, _ = c.poker.GetCards(1)
+	cards, _ := c.poker.GetCards(n)
+	c.secondBoard = append(c.secondBoard, cards...)
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	for _, r := range c.roomers {
+		r.recv.RoomerGetSecondBoard(c.id, cards)
+	}
+	c.log.Debug("deal second board", zap.Int("cards_count", n))
+}
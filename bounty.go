@@ -0,0 +1,98 @@
+package holdem
+
+import "go.uber.org/zap"
+
+//BountyAward 淘汰玩家获得的赏金
+type BountyAward struct {
+	SeatNumber int8
+	UserID     string
+	//Eliminated 被淘汰的玩家
+	Eliminated string
+	//Cash 获得的赏金
+	Cash uint
+	//Added 加到自己赏金上的部分(渐进式)
+	Added uint
+}
+
+//bountyState 每个玩家身上的赏金
+type bountyState struct {
+	amount      uint
+	progressive bool
+	bounties    map[string]uint
+}
+
+//get 玩家当前的赏金(没有记录的为初始赏金)
+func (c *bountyState) get(id string) uint {
+	if v, ok := c.bounties[id]; ok {
+		return v
+	}
+	return c.amount
+}
+
+//awardBounties 输光的玩家的赏金按赢得的份额分给淘汰他的那个池(他参与的最后一个池)的赢家(有锁)
+func (c *Holdem) awardBounties(users []*Agent, pots []*Pot, potWins []map[int8]uint) map[int8][]*BountyAward {
+	ret := make(map[int8][]*BountyAward)
+	if c.bounty == nil {
+		return ret
+	}
+	s := c.bounty
+	for _, u := range users {
+		if u.gameInfo.chip > 0 || u.gameInfo.pendingChip > 0 {
+			continue
+		}
+		idx := -1
+		for i, pot := range pots {
+			if pot.SeatNumber[u.gameInfo.seatNumber] {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			continue
+		}
+		wins := potWins[idx]
+		var total uint
+		for _, num := range wins {
+			total += num
+		}
+		if total == 0 {
+			continue
+		}
+		bounty := s.get(u.id)
+		//被淘汰后重新计算赏金(重购/重新参赛)
+		delete(s.bounties, u.id)
+		//按庄位后的顺序分配,零头给第一个赢家
+		awards := make([]*BountyAward, 0, len(wins))
+		var given uint
+		w := c.button.nextAgent
+		for {
+			if num, ok := wins[w.gameInfo.seatNumber]; ok && num > 0 {
+				share := bounty * num / total
+				given += share
+				awards = append(awards, &BountyAward{
+					SeatNumber: w.gameInfo.seatNumber,
+					UserID:     w.id,
+					Eliminated: u.id,
+					Cash:       share,
+				})
+			}
+			if w == c.button {
+				break
+			}
+			w = w.nextAgent
+		}
+		if len(awards) == 0 {
+			continue
+		}
+		awards[0].Cash += bounty - given
+		for _, a := range awards {
+			if s.progressive {
+				a.Added = a.Cash / 2
+				a.Cash -= a.Added
+				s.bounties[a.UserID] = s.get(a.UserID) + a.Added
+			}
+			ret[a.SeatNumber] = append(ret[a.SeatNumber], a)
+			c.log.Debug("bounty", zap.String("user", a.UserID), zap.String("eliminated", u.id), zap.Uint("cash", a.Cash), zap.Uint("added", a.Added))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,88 @@
+package holdem
+
+import "go.uber.org/zap"
+
+//limitBuyIn 按带入限制调整带入数量(stack为现有筹码,没有筹码时至少带入到最小带入,超过最大带入的部分不带入)
+//离桌后很快回来的至少要带入离桌时的筹码(可以超过最大带入)
+func (c *Holdem) limitBuyIn(r *Agent, stack uint, chip uint) (uint, *errorWithCode) {
+	min := c.options.minBuyIn * c.bb
+	max := c.options.maxBuyIn * c.bb
+	if stack == 0 {
+		if need := c.ratholeChip(r.id); need > 0 {
+			if chip < need {
+				return 0, &errorWithCode{ErrCodeBuyInRathole, errBuyInRathole}
+			}
+			if max > 0 && max < need {
+				max = need
+			}
+		}
+	}
+	if max > 0 {
+		if stack >= max {
+			return 0, &errorWithCode{ErrCodeBuyInTooMuch, errBuyInTooMuch}
+		}
+		if stack+chip > max {
+			chip = max - stack
+		}
+	}
+	if stack == 0 && chip < min {
+		return 0, &errorWithCode{ErrCodeBuyInTooLittle, errBuyInTooLittle}
+	}
+	return chip, nil
+}
+
+//bringIn 带入(没有坐下时直接加上,坐下后游戏开始了等这手结束再加上)
+func (c *Holdem) bringIn(r *Agent, chip uint) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	var stack uint
+	if r.gameInfo != nil {
+		stack = r.gameInfo.chip + r.gameInfo.pendingChip
+	}
+	chip, err := c.limitBuyIn(r, stack, chip)
+	if err != nil {
+		r.recv.ErrorOccur(c.id, err.code, err.err)
+		return
+	}
+	if r.gameInfo == nil {
+		r.gameInfo = &gameInfo{}
+	}
+	switch {
+	case r.gameInfo.seatNumber > 0 && c.status() != GameStatusNotStart:
+		r.gameInfo.pendingChip += chip
+		c.log.Debug("user bring in(queued)", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("id", r.id), zap.Uint("bringin", chip))
+	case r.gameInfo.seatNumber > 0:
+		//坐着时直接入账(没有坐下的在坐下时入账)
+		c.transferChip(playerAccount(r.id), accountCashier, chip, "bring in")
+		fallthrough
+	default:
+		r.gameInfo.bringIn += chip
+		r.gameInfo.chip += chip
+		c.log.Debug("user bring in", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("id", r.id), zap.Uint("bringin", chip))
+	}
+	r.recv.PlayerBringInSuccess(c.id, r.gameInfo.seatNumber, r.id, chip)
+}
+
+//autoTopUp 两手之间把打开自动补码的玩家补到目标筹码
+func (c *Holdem) autoTopUp() {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	for _, r := range c.players {
+		if r.gameInfo.autoTopUp == 0 {
+			continue
+		}
+		target := r.gameInfo.autoTopUp * c.bb
+		if r.gameInfo.chip >= target {
+			continue
+		}
+		chip, err := c.limitBuyIn(r, r.gameInfo.chip, target-r.gameInfo.chip)
+		if err != nil || chip == 0 {
+			continue
+		}
+		r.gameInfo.chip += chip
+		r.gameInfo.bringIn += chip
+		c.transferChip(playerAccount(r.id), accountCashier, chip, "auto top up")
+		c.log.Debug("user auto top up", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("id", r.id), zap.Uint("chip", chip))
+		r.recv.PlayerBringInSuccess(c.id, r.gameInfo.seatNumber, r.id, chip)
+	}
+}
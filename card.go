@@ -100,22 +100,62 @@ func (c Card) Value() int8 {
 	return c.Suit*15 + c.Num
 }
 
+//HandRanking 牌型大小规则
+type HandRanking struct {
+	//ShortDeck 短牌(6+),A-6-7-8-9为最小的顺子
+	ShortDeck bool
+	//FlushBeatsFullHouse 同花大于葫芦
+	FlushBeatsFullHouse bool
+	//TripsBeatStraight 三条大于顺子
+	TripsBeatStraight bool
+}
+
+var (
+	//DefaultHandRanking 标准德州规则
+	DefaultHandRanking = &HandRanking{}
+	//ShortDeckHandRanking 短牌规则(同花大于葫芦)
+	ShortDeckHandRanking = &HandRanking{
+		ShortDeck:           true,
+		FlushBeatsFullHouse: true,
+	}
+)
+
+//rank 牌型在当前规则下的大小顺序
+func (c *HandRanking) rank(t HandValueType) int64 {
+	switch {
+	case c.FlushBeatsFullHouse && t == HVFlush:
+		return int64(HVFullHouse)
+	case c.FlushBeatsFullHouse && t == HVFullHouse:
+		return int64(HVFlush)
+	case c.TripsBeatStraight && t == HVThreeOfAKind:
+		return int64(HVStraight)
+	case c.TripsBeatStraight && t == HVStraight:
+		return int64(HVThreeOfAKind)
+	}
+	return int64(t)
+}
+
 //HandValue 手牌
 type HandValue struct {
 	cards            [5]*Card
 	value            int64
 	maxHandValueType HandValueType
+	ranking          *HandRanking
 }
 
-//NewHandValue 创建手牌（已计算最高牌型）
-func NewHandValue(nc []*Card) (*HandValue, error) {
+//NewHandValue 创建手牌（已计算最高牌型,不传规则使用标准规则）
+func NewHandValue(nc []*Card, rk ...*HandRanking) (*HandValue, error) {
 	if len(nc) != 5 {
 		return nil, errors.New("cards length is not 5")
 	}
 	var a [5]*Card
 	copy(a[:], nc)
 	t := &HandValue{
-		cards: a,
+		cards:   a,
+		ranking: DefaultHandRanking,
+	}
+	if len(rk) > 0 && rk[0] != nil {
+		t.ranking = rk[0]
 	}
 	t.evaluate()
 	return t, nil
@@ -211,27 +251,28 @@ func (c *HandValue) String() string {
 }
 
 func (c *HandValue) caculateValue() {
+	rank := c.ranking.rank(c.maxHandValueType)
 	switch c.maxHandValueType {
 	case HVHighCard:
 		c.value = int64(c.cards[0].Num)<<16 + int64(c.cards[1].Num)<<12 + int64(c.cards[2].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
 	case HVOnePair:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<12 + int64(c.cards[2].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<12 + int64(c.cards[2].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
 	case HVTwoPair:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
 	case HVThreeOfAKind:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
 	case HVStraight:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)
 	case HVFlush:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<16 + int64(c.cards[1].Num)<<12 + int64(c.cards[2].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<16 + int64(c.cards[1].Num)<<12 + int64(c.cards[2].Num)<<8 + int64(c.cards[3].Num)<<4 + int64(c.cards[4].Num)
 	case HVFullHouse:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<4 + int64(c.cards[3].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<4 + int64(c.cards[3].Num)
 	case HVFourOfAKind:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)<<4 + int64(c.cards[4].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)<<4 + int64(c.cards[4].Num)
 	case HVStraightFlush:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)
 	case HVRoyalFlush:
-		c.value = int64(c.maxHandValueType)<<20 + int64(c.cards[0].Num)
+		c.value = rank<<20 + int64(c.cards[0].Num)
 	default:
 		panic(ErrInvalidHandValueType)
 	}
@@ -264,6 +305,11 @@ func (c *HandValue) isStraight() bool {
 		c.cards[0], c.cards[1], c.cards[2], c.cards[3], c.cards[4] = c.cards[1], c.cards[2], c.cards[3], c.cards[4], c.cards[0]
 		return true
 	}
+	//短牌 A-6-7-8-9
+	if c.ranking.ShortDeck && c.cards[0].Num == 14 && c.cards[1].Num == 9 && c.cards[2].Num == 8 && c.cards[3].Num == 7 && c.cards[4].Num == 6 {
+		c.cards[0], c.cards[1], c.cards[2], c.cards[3], c.cards[4] = c.cards[1], c.cards[2], c.cards[3], c.cards[4], c.cards[0]
+		return true
+	}
 	for i = 1; i < 5; i++ {
 		if (c.cards[0].Num - c.cards[i].Num) != i {
 			return false
@@ -0,0 +1,153 @@
+package holdem
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+//DeckSource 洗牌来源(每手开始洗整副牌,多次发牌时重洗剩余的牌),按cards的顺序发牌
+type DeckSource interface {
+	Shuffle(cards []*Card)
+}
+
+//EntropySource 可以取出洗牌用到的随机数的洗牌来源(每手洗牌后由记录器记录,用于审计)
+type EntropySource interface {
+	DeckSource
+	//Entropy 取出上次取出后洗牌用到的随机数
+	Entropy() []byte
+}
+
+//cryptoSource 默认用crypto/rand洗牌(无偏的Fisher-Yates),记录用到的随机数
+type cryptoSource struct {
+	mu      sync.Mutex
+	entropy []byte
+}
+
+var _ EntropySource = (*cryptoSource)(nil)
+
+func (c *cryptoSource) Shuffle(cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	used, err := shuffleFrom(crand.Reader, cards)
+	c.entropy = append(c.entropy, used...)
+	if err != nil {
+		panic("holdem: crypto/rand failed: " + err.Error())
+	}
+}
+
+func (c *cryptoSource) Entropy() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := c.entropy
+	c.entropy = nil
+	return ret
+}
+
+//shuffleFrom 用r中的随机数洗牌,返回读取的所有随机数(包括拒绝采样丢掉的)
+func shuffleFrom(r io.Reader, cards []*Card) ([]byte, error) {
+	used := make([]byte, 0, 4*len(cards))
+	buf := make([]byte, 4)
+	for i := len(cards) - 1; i > 0; i-- {
+		n := uint32(i + 1)
+		//拒绝采样,去掉取模的偏差
+		limit := math.MaxUint32 - math.MaxUint32%n
+		for {
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return used, err
+			}
+			used = append(used, buf...)
+			if v := binary.BigEndian.Uint32(buf); v < limit {
+				j := v % n
+				cards[i], cards[j] = cards[j], cards[i]
+				break
+			}
+		}
+	}
+	return used, nil
+}
+
+//ReplayShuffle 用记录的随机数重现洗牌(cards为洗牌前的顺序,每手从原始顺序开始),审计时和实际发出的牌对比
+func ReplayShuffle(cards []*Card, entropy []byte) ([]*Card, error) {
+	ret := append(make([]*Card, 0, len(cards)), cards...)
+	if _, err := shuffleFrom(bytes.NewReader(entropy), ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+//seededSource 固定种子(同样的种子和同样的操作得到同样的牌)
+type seededSource struct {
+	mu sync.Mutex
+	rd *rand.Rand
+}
+
+var _ DeckSource = (*seededSource)(nil)
+
+//NewSeededDeckSource 固定种子的洗牌来源(用于测试和复盘)
+func NewSeededDeckSource(seed int64) DeckSource {
+	return &seededSource{
+		rd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (c *seededSource) Shuffle(cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rd.Shuffle(len(cards), func(i int, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+}
+
+//fixedSource 预先安排好的牌序
+type fixedSource struct {
+	mu     sync.Mutex
+	orders [][]*Card
+}
+
+var _ DeckSource = (*fixedSource)(nil)
+
+//NewFixedDeckSource 按顺序发出预先安排好的牌(每次洗牌用下一组,排好的牌放在最前面,其余的牌保持原来的顺序,用完后不再洗牌)
+func NewFixedDeckSource(orders ...[]*Card) DeckSource {
+	return &fixedSource{
+		orders: orders,
+	}
+}
+
+func (c *fixedSource) Shuffle(cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.orders) == 0 {
+		return
+	}
+	order := c.orders[0]
+	c.orders = c.orders[1:]
+	idx := 0
+	for _, want := range order {
+		for i := idx; i < len(cards); i++ {
+			if cards[i].Value() == want.Value() {
+				//挪到前面(中间的牌依次后移,保持顺序)
+				cd := cards[i]
+				copy(cards[idx+1:i+1], cards[idx:i])
+				cards[idx] = cd
+				idx++
+				break
+			}
+		}
+	}
+}
+
+//recordEntropy 记录本次洗牌用到的随机数
+func (c *Holdem) recordEntropy() {
+	s, ok := c.poker.source.(EntropySource)
+	if !ok {
+		return
+	}
+	if entropy := s.Entropy(); len(entropy) > 0 {
+		c.options.recorder.ShuffleEntropy(c.base(), entropy)
+	}
+}
@@ -32,6 +32,7 @@ const (
 	StandUpGameForce
 	StandUpGameExchange
 	StandUpAutoExceedMaxTimes
+	StandUpEliminated
 )
 
 func (c Round) String() string {
@@ -61,6 +62,7 @@ const (
 	ActionDefCheck
 	ActionDefRaise
 	ActionDefAllIn
+	ActionDefStraddle
 )
 
 func (c ActionDef) String() string {
@@ -83,6 +85,8 @@ func (c ActionDef) String() string {
 		return "raise"
 	case ActionDefAllIn:
 		return "all in"
+	case ActionDefStraddle:
+		return "straddle"
 	default:
 		return "ready"
 	}
@@ -19,6 +19,24 @@ const (
 	ErrCodeAlreadySeated
 	ErrCodeGameOver
 	ErrCodeExceedTimeOverTimes
+	ErrCodeCannotStraddle
+	ErrCodeInvalidRunTimes
+	ErrCodeCannotRabbitHunt
+	ErrCodeRabbitHuntOverTimes
+	ErrCodeRebuyNotAllowed
+	ErrCodeRebuyClosed
+	ErrCodeRebuyOverTimes
+	ErrCodeRebuyTooManyChips
+	ErrCodeAddOnNotAllowed
+	ErrCodeAddOnOverTimes
+	ErrCodeReEntryNotAllowed
+	ErrCodeReEntryOverTimes
+	ErrCodeBuyInTooLittle
+	ErrCodeBuyInTooMuch
+	ErrCodeBuyInRathole
+	ErrCodeFairNotEnabled
+	ErrCodeFairNoCommitment
+	ErrCodePlayerNotAtTable
 )
 
 type errorWithCode struct {
@@ -43,4 +61,22 @@ var (
 	errAlreadySeated         = errors.New("you are already seated")
 	errGameOver              = errors.New("game is over")
 	errExceedTimeOverTimes   = errors.New("can not exceed time")
+	errCannotStraddle        = errors.New("straddle is not allowed")
+	errInvalidRunTimes       = errors.New("invalid run times")
+	errCannotRabbitHunt      = errors.New("rabbit hunt is not allowed")
+	errRabbitHuntOverTimes   = errors.New("rabbit hunt times is over limit")
+	errRebuyNotAllowed       = errors.New("rebuy is not allowed")
+	errRebuyClosed           = errors.New("rebuy period is over")
+	errRebuyOverTimes        = errors.New("rebuy times is over limit")
+	errRebuyTooManyChips     = errors.New("chip is over rebuy threshold")
+	errAddOnNotAllowed       = errors.New("add-on is only allowed at the break")
+	errAddOnOverTimes        = errors.New("add-on is already taken")
+	errReEntryNotAllowed     = errors.New("re-entry is not allowed")
+	errReEntryOverTimes      = errors.New("re-entry times is over limit")
+	errBuyInTooLittle        = errors.New("bring in is less than minimum buy-in")
+	errBuyInTooMuch          = errors.New("chip is already at maximum buy-in")
+	errBuyInRathole          = errors.New("must bring in at least the chip you left with")
+	errFairNotEnabled        = errors.New("provably fair mode is not enabled")
+	errFairNoCommitment      = errors.New("next hand is not committed yet, send client seed after the commitment")
+	errPlayerNotAtTable      = errors.New("player is not seated at this table")
 )
@@ -0,0 +1,208 @@
+package holdem
+
+import "math/bits"
+
+//CardBits 按位打包的牌(Cactus Kev格式)
+//xxxbbbbb bbbbbbbb cdhsrrrr xxpppppp b:点数位 cdhs:花色位 r:点数(0-12) p:点数对应的质数
+type CardBits uint32
+
+var rankPrimes = [13]uint32{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+var (
+	straightTable [8192]int8  //点数位对应的最大顺子(顺子最大的牌,没有为0)
+	topFiveTable  [8192]int64 //点数位中最大的5张(每4位一张,从高到低)
+)
+
+func init() {
+	for m := 0; m < 8192; m++ {
+		//A-2-3-4-5
+		if m&0x100f == 0x100f {
+			straightTable[m] = 5
+		}
+		for r := 0; r+4 < 13; r++ {
+			if m>>uint(r)&0x1f == 0x1f {
+				straightTable[m] = int8(r + 6)
+			}
+		}
+		var v int64
+		n := 0
+		for r := 12; r >= 0 && n < 5; r-- {
+			if m&(1<<uint(r)) != 0 {
+				v |= int64(r+2) << uint(16-4*n)
+				n++
+			}
+		}
+		topFiveTable[m] = v
+	}
+}
+
+//Bits 按位打包
+func (c Card) Bits() CardBits {
+	r := uint32(c.Num - 2)
+	return CardBits(1<<(16+r) | 0x1000<<uint32(c.Suit) | r<<8 | rankPrimes[r])
+}
+
+//Num 点数(2-14)
+func (c CardBits) Num() int8 {
+	return int8(c>>8&0xf) + 2
+}
+
+//Suit 花色(0-3)
+func (c CardBits) Suit() int8 {
+	return int8(bits.TrailingZeros32(uint32(c >> 12 & 0xf)))
+}
+
+//EvaluateBits 快速计算5-7张牌的最大牌型(不生成组合,值和GetMaxHandValueFromCard得到的Value()一致),牌数不对时返回0
+func EvaluateBits(cards []CardBits, rk ...*HandRanking) (int64, HandValueType) {
+	if len(cards) < 5 || len(cards) > 7 {
+		return 0, 0
+	}
+	ranking := DefaultHandRanking
+	if len(rk) > 0 && rk[0] != nil {
+		ranking = rk[0]
+	}
+	var suits [4]uint32
+	var counts [13]uint8
+	for _, c := range cards {
+		rb := uint32(c >> 16 & 0x1fff)
+		suits[bits.TrailingZeros32(uint32(c>>12&0xf))] |= rb
+		counts[c>>8&0xf]++
+	}
+	//同花/同花顺(7张牌有同花时不会有四条和葫芦)
+	for _, m := range suits {
+		if bits.OnesCount32(m) < 5 {
+			continue
+		}
+		if top := straightOf(m, ranking); top > 0 {
+			t := HVStraightFlush
+			if top == 14 {
+				t = HVRoyalFlush
+			}
+			return ranking.rank(t)<<20 + int64(top), t
+		}
+		return ranking.rank(HVFlush)<<20 + topFiveTable[m], HVFlush
+	}
+	mask := suits[0] | suits[1] | suits[2] | suits[3]
+	var quad, trip1, trip2, pair1, pair2, pair3 int64
+	for r := 12; r >= 0; r-- {
+		n := int64(r + 2)
+		switch counts[r] {
+		case 4:
+			quad = n
+		case 3:
+			if trip1 == 0 {
+				trip1 = n
+			} else if trip2 == 0 {
+				trip2 = n
+			}
+		case 2:
+			if pair1 == 0 {
+				pair1 = n
+			} else if pair2 == 0 {
+				pair2 = n
+			} else if pair3 == 0 {
+				pair3 = n
+			}
+		}
+	}
+	if quad > 0 {
+		kicker := topFiveTable[mask&^(1<<uint(quad-2))] >> 16
+		return ranking.rank(HVFourOfAKind)<<20 + quad<<4 + kicker, HVFourOfAKind
+	}
+	var value int64
+	var best HandValueType
+	try := func(v int64, t HandValueType) {
+		if v > value {
+			value, best = v, t
+		}
+	}
+	if trip1 > 0 && (trip2 > 0 || pair1 > 0) {
+		pair := pair1
+		if trip2 > pair {
+			pair = trip2
+		}
+		try(ranking.rank(HVFullHouse)<<20+trip1<<4+pair, HVFullHouse)
+	}
+	if top := straightOf(mask, ranking); top > 0 {
+		try(ranking.rank(HVStraight)<<20+int64(top), HVStraight)
+	}
+	if best != 0 && !ranking.TripsBeatStraight {
+		return value, best
+	}
+	switch {
+	case trip1 > 0:
+		kickers := topFiveTable[mask&^(1<<uint(trip1-2))] >> 12
+		try(ranking.rank(HVThreeOfAKind)<<20+trip1<<8+kickers, HVThreeOfAKind)
+	case pair2 > 0:
+		kicker := topFiveTable[mask&^(1<<uint(pair1-2))&^(1<<uint(pair2-2))] >> 16
+		try(ranking.rank(HVTwoPair)<<20+pair1<<8+pair2<<4+kicker, HVTwoPair)
+	case pair1 > 0:
+		kickers := topFiveTable[mask&^(1<<uint(pair1-2))] >> 8
+		try(ranking.rank(HVOnePair)<<20+pair1<<12+kickers, HVOnePair)
+	default:
+		try(topFiveTable[mask], HVHighCard)
+	}
+	return value, best
+}
+
+//straightOf 点数位中最大的顺子(短牌A-6-7-8-9为9)
+func straightOf(mask uint32, ranking *HandRanking) int8 {
+	top := straightTable[mask]
+	if top == 0 && ranking.ShortDeck && mask&0x10f0 == 0x10f0 {
+		top = 9
+	}
+	return top
+}
+
+//FastHandValue 用按位打包的牌快速计算5-7张牌的最大牌型(值和GetMaxHandValueFromCard得到的Value()一致)
+//只有值和牌型,没有组成牌型的5张牌(不能TaggingCards)
+func FastHandValue(nc []*Card, rk ...*HandRanking) (int64, HandValueType, error) {
+	if len(nc) < 5 || len(nc) > 7 {
+		return 0, 0, ErrInvalidCardLength
+	}
+	var cb [7]CardBits
+	for i, c := range nc {
+		cb[i] = c.Bits()
+	}
+	v, t := EvaluateBits(cb[:len(nc)], rk...)
+	return v, t, nil
+}
+
+//bitsValue 手牌加公共牌(5-7张)的最大牌型值
+func bitsValue(hole []*Card, board []*Card, ranking *HandRanking) (int64, HandValueType) {
+	var cb [7]CardBits
+	n := 0
+	for _, cs := range [][]*Card{board, hole} {
+		for _, c := range cs {
+			if n == len(cb) {
+				return 0, 0
+			}
+			cb[n] = c.Bits()
+			n++
+		}
+	}
+	return EvaluateBits(cb[:n], ranking)
+}
+
+//omahaBitsValue 奥马哈(2张手牌+3张公共牌)的最大牌型值
+func omahaBitsValue(hole []*Card, board []*Card, ranking *HandRanking) (int64, HandValueType) {
+	var max int64
+	var best HandValueType
+	cb := make([]CardBits, 5)
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			cb[0], cb[1] = hole[i].Bits(), hole[j].Bits()
+			for a := 0; a < len(board); a++ {
+				for b := a + 1; b < len(board); b++ {
+					for d := b + 1; d < len(board); d++ {
+						cb[2], cb[3], cb[4] = board[a].Bits(), board[b].Bits(), board[d].Bits()
+						if v, tp := EvaluateBits(cb, ranking); v > max {
+							max, best = v, tp
+						}
+					}
+				}
+			}
+		}
+	}
+	return max, best
+}
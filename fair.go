@@ -0,0 +1,196 @@
+package holdem
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+var ErrFairHashMismatch = errors.New("revealed seed and deck do not match the commitment")
+var ErrFairDeckMismatch = errors.New("revealed deck can not be recomputed from the seeds")
+
+//FairCommitment 可验证公平模式在接受玩家种子之前公布的承诺(上一手结束时公布下一手的,加入时也会收到)
+type FairCommitment struct {
+	HandNum uint
+	//Deck 洗牌前的原始牌序
+	Deck []*Card
+	//Hash sha256(服务器种子:原始牌序)的16进制
+	Hash string
+}
+
+//FairReveal 可验证公平模式每手结束后公开的种子和牌序
+type FairReveal struct {
+	HandNum uint
+	//ServerSeed 服务器种子的16进制
+	ServerSeed string
+	//ClientSeed 承诺公布后提交的玩家种子(按用户ID排序后拼接)
+	ClientSeed string
+	//Deck 本手洗好的整副牌
+	Deck []*Card
+}
+
+//fairHand 一手的种子(发牌前公布哈希,结束后公开)
+type fairHand struct {
+	handNum    uint
+	serverSeed []byte
+	base       []*Card
+	clientSeed string
+	deck       []*Card
+}
+
+//newFairHand 生成服务器种子
+func newFairHand(handNum uint, base []*Card) *fairHand {
+	seed := make([]byte, 32)
+	if _, err := crand.Read(seed); err != nil {
+		panic("holdem: crypto/rand failed: " + err.Error())
+	}
+	return &fairHand{
+		handNum:    handNum,
+		serverSeed: seed,
+		base:       append(make([]*Card, 0, len(base)), base...),
+	}
+}
+
+//commitment 承诺
+func (c *fairHand) commitment() *FairCommitment {
+	return &FairCommitment{
+		HandNum: c.handNum,
+		Deck:    c.base,
+		Hash:    fairHash(c.serverSeed, c.base),
+	}
+}
+
+//fairStream 用种子生成的随机数(HMAC-SHA256(服务器种子,玩家种子:手数:序号))
+type fairStream struct {
+	key     []byte
+	prefix  string
+	counter uint
+	buf     []byte
+}
+
+func (c *fairStream) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(c.buf) == 0 {
+			mac := hmac.New(sha256.New, c.key)
+			fmt.Fprintf(mac, "%s:%d", c.prefix, c.counter)
+			c.buf = mac.Sum(nil)
+			c.counter++
+		}
+		m := copy(p[n:], c.buf)
+		c.buf = c.buf[m:]
+		n += m
+	}
+	return n, nil
+}
+
+//fairDeck 用种子从原始顺序洗牌
+func fairDeck(deck []*Card, serverSeed []byte, clientSeed string, handNum uint) []*Card {
+	cards := append(make([]*Card, 0, len(deck)), deck...)
+	_, _ = shuffleFrom(&fairStream{key: serverSeed, prefix: fmt.Sprintf("%s:%d", clientSeed, handNum)}, cards)
+	return cards
+}
+
+//fairHash 承诺的哈希
+func fairHash(serverSeed []byte, deck []*Card) string {
+	var sb strings.Builder
+	sb.WriteString(hex.EncodeToString(serverSeed))
+	sb.WriteString(":")
+	for _, cd := range deck {
+		sb.WriteString(cd.String())
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+//VerifyFairHand 检查公开的服务器种子和承诺一致,再用承诺的原始牌序和两边的种子重新洗牌,检查和公开的牌序是否一致
+//玩家还应该检查自己的种子在ClientSeed中
+func VerifyFairHand(commit *FairCommitment, reveal *FairReveal) error {
+	seed, err := hex.DecodeString(reveal.ServerSeed)
+	if err != nil {
+		return err
+	}
+	if commit.HandNum != reveal.HandNum || fairHash(seed, commit.Deck) != commit.Hash {
+		return ErrFairHashMismatch
+	}
+	cards := fairDeck(commit.Deck, seed, reveal.ClientSeed, reveal.HandNum)
+	if len(cards) != len(reveal.Deck) {
+		return ErrFairDeckMismatch
+	}
+	for i, cd := range cards {
+		if cd.Value() != reveal.Deck[i].Value() {
+			return ErrFairDeckMismatch
+		}
+	}
+	return nil
+}
+
+//clientSeeds 在这一手的承诺公布后提交种子的玩家的种子(按用户ID排序后拼接)(有锁)
+func (c *Holdem) clientSeeds(handNum uint) string {
+	seeds := make(map[string]string)
+	ids := make([]string, 0, len(c.players))
+	for _, r := range c.players {
+		if r.gameInfo.clientSeed != "" && r.gameInfo.clientSeedHand == handNum {
+			seeds[r.id] = r.gameInfo.clientSeed
+			ids = append(ids, r.id)
+		}
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, id+"="+seeds[id])
+	}
+	return strings.Join(parts, ",")
+}
+
+//fairShuffle 可验证公平模式用已经公布承诺的服务器种子和之后提交的玩家种子洗牌
+func (c *Holdem) fairShuffle() {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	f := c.fairNext
+	if f == nil || f.handNum != c.handNum {
+		//没有这一手的承诺时重新公布(之前提交的玩家种子都不用)
+		f = newFairHand(c.handNum, c.poker.deck)
+		c.commitFair(f)
+	}
+	c.fairNext = nil
+	f.clientSeed = c.clientSeeds(f.handNum)
+	f.deck = fairDeck(f.base, f.serverSeed, f.clientSeed, f.handNum)
+	c.poker.arrange(f.deck)
+	c.fair = f
+}
+
+//commitFair 公布下一手的承诺(有锁)
+func (c *Holdem) commitFair(f *fairHand) {
+	c.fairNext = f
+	commit := f.commitment()
+	c.log.Debug("fair commitment", zap.Uint("hand", commit.HandNum), zap.String("hash", commit.Hash))
+	for _, r := range c.roomers {
+		r.recv.RoomerGetFairCommitment(c.id, commit)
+	}
+}
+
+//revealFair 一手结束后公开种子,并公布下一手的承诺(有锁)
+func (c *Holdem) revealFair() {
+	if c.fair == nil {
+		return
+	}
+	reveal := &FairReveal{
+		HandNum:    c.fair.handNum,
+		ServerSeed: hex.EncodeToString(c.fair.serverSeed),
+		ClientSeed: c.fair.clientSeed,
+		Deck:       c.fair.deck,
+	}
+	c.fair = nil
+	for _, r := range c.roomers {
+		r.recv.RoomerGetFairReveal(c.id, reveal)
+	}
+	c.commitFair(newFairHand(c.handNum+1, c.poker.deck))
+}
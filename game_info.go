@@ -38,31 +38,38 @@ type gameInfo struct {
 	handNum           uint
 	cardResults       []*CardResult
 	insurance         map[int8]*BuyInsurance //CardValue: buy
+	transfer          *transfer              //两手之间换桌
 	autoHandNum       uint
 	autoFoldTimes     uint
 	autoCheckTimes    uint
-	delayTimes        uint //延时次数
+	delayTimes        uint   //延时次数
+	acted             bool   //本轮是否已行动
+	straddle          bool   //下一手抓头
+	rabbitHuntTimes   uint   //看剩余公共牌次数
+	pendingChip       uint   //重购/加码/手中带入等待下一手加上的筹码
+	autoTopUp         uint   //两手之间自动补到的筹码(大盲数,0为不开启)
+	clientSeed        string //可验证公平模式的玩家种子
+	clientSeedHand    uint   //玩家种子提交时已经公布承诺的手数
 }
 
-func (c *gameInfo) calcHandValue(pc []*Card) {
+func (c *gameInfo) calcHandValue(pc []*Card, eval func(hole []*Card, board []*Card) (*HandValue, error)) {
 	if c.handValue != nil {
 		return
 	}
-	tmp := make([]*Card, 0, 7)
+	tmp := make([]*Card, 0, len(pc)+len(c.cards))
 	tmp = append(tmp, pc...)
 	tmp = append(tmp, c.cards...)
-	c.handValue, _ = GetMaxHandValueFromCard(tmp)
+	c.handValue, _ = eval(c.cards, pc)
 	mp := c.handValue.TaggingCards(tmp)
-	c.cardResults = make([]*CardResult, 0, 7)
-	for i, v := range pc {
+	c.cardResults = make([]*CardResult, 0, len(tmp))
+	for i, v := range tmp {
 		c.cardResults = append(c.cardResults, NewCardResult(v, mp[i]))
 	}
-	c.cardResults = append(c.cardResults, NewCardResult(c.cards[0], mp[5]))
-	c.cardResults = append(c.cardResults, NewCardResult(c.cards[1], mp[6]))
 }
 
 func (c *gameInfo) resetForNextHand() {
 	c.status = ActionDefNone
+	c.acted = false
 	c.roundBet = 0
 	c.handBet = 0
 	c.cards = nil
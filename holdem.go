@@ -21,6 +21,16 @@ type StartNewHandInfo struct {
 	BBSeat int8
 	//PayToPlay 做了补盲的用户数组
 	PayToPlay []int8
+	//Straddles 抓头下注信息(按抓头顺序)
+	Straddles []*StraddleBet
+	//BombPot 炸弹底池信息(nil为普通牌局)
+	BombPot *BombPot
+}
+
+//StraddleBet 抓头下注信息
+type StraddleBet struct {
+	SeatNumber int8
+	Bet        *Bet
 }
 
 type HoldemBase struct {
@@ -47,9 +57,15 @@ type HoldemState struct {
 	EmptySeats  []int8
 	Pot         uint
 	PublicCards []*Card
+	//SecondBoard 第二组公共牌(双公共牌炸弹底池)
+	SecondBoard []*Card
 	Onlines     uint
 	Paused      bool
 	Insurance   map[int8]map[int8][]*UserOut
+	//Rake 本手的抽水
+	Rake uint
+	//JackpotDrop 本手注入坏牌奖池的数量
+	JackpotDrop uint
 }
 
 type Holdem struct {
@@ -73,12 +89,22 @@ type Holdem struct {
 	handStartInfo        *StartNewHandInfo                   //当前一手开局信息
 	sb                   uint                                //小盲
 	nextSb               int                                 //即将修改的小盲
+	bb                   uint                                //大盲
+	nextBb               int                                 //即将修改的大盲(没有时为小盲的2倍)
 	ante                 uint                                //前注
 	nextAnte             int                                 //即将修改的前注
 	pot                  uint                                //彩池
 	roundBet             uint                                //当前轮下注额
 	minRaise             uint                                //最小加注量
+	raiseTimes           uint                                //当前轮下注/加注次数
+	straddler            *Agent                              //本手最后一个抓头的人
+	runVoted             bool                                //本手是否已经选择过发牌次数
 	publicCards          []*Card                             //公共牌
+	secondBoard          []*Card                             //第二组公共牌(双公共牌)
+	bombPot              *BombPot                            //本手炸弹底池
+	nextBombPot          *BombPot                            //下一手炸弹底池
+	rabbitCards          []*Card                             //提前结束时剩余的公共牌
+	rabbitHunted         bool                                //本手剩余公共牌是否已经展示
 	log                  *zap.Logger                         //日志
 	nextGame             func(*HoldemState) bool             //是否继续下一轮的回调函数和等待下一手时间(当前手数) - 内部可以用各种条件来判断是否继续
 	insuranceInformation map[int8]map[int8][]*UserOut        //当前保险Outs 可以买保险的Seat:对应Outs的座位号:[]outs
@@ -86,6 +112,19 @@ type Holdem struct {
 	insuranceUsers       []*Agent                            //参与保险的玩家
 	waitDeadline         time.Time                           //等待的截止时间
 	paused               bool                                //暂停
+	stopLock             int32                               //是否结束原子锁
+	sng                  *sitAndGo                           //单桌赛状态
+	blinds               *blindState                         //盲注表进度
+	rebuy                *rebuyState                         //重购/加码/重新参赛记录
+	bounty               *bountyState                        //赏金
+	ledger               *ledger                             //筹码账本
+	transfers            []*transfer                         //等待执行的换桌
+	reserved             map[int8]string                     //给换桌过来的玩家预留的座位
+	departures           map[string]*departure               //离桌玩家的筹码(防止带走筹码后少量带入)
+	fair                 *fairHand                           //本手可验证公平的种子
+	fairNext             *fairHand                           //已经公布承诺的下一手的种子
+	rake                 uint                                //本手的抽水
+	jackpotDropped       uint                                //本手注入奖池的数量
 	pauseCh              chan bool                           //暂停通道
 	options              *extOptions                         //额外配置
 }
@@ -119,6 +158,8 @@ func NewHoldem(
 		limitDelayTimes:         2,
 		limitAutoCheckTimes:     4,
 		limitAutoFoldTimes:      3,
+		holeCards:               2,
+		betting:                 &NoLimit{},
 	}
 	for _, o := range ops {
 		o.apply(exts)
@@ -137,9 +178,16 @@ func NewHoldem(
 			exts.autoMinPlayers = 2
 		}
 	}
+	poker := NewPoker()
+	if exts.shortDeck {
+		poker = NewShortDeckPoker()
+	}
+	if exts.deckSource != nil {
+		poker.source = exts.deckSource
+	}
 	h := &Holdem{
 		id:             id,
-		poker:          NewPoker(),
+		poker:          poker,
 		players:        make(map[int8]*Agent),
 		roomers:        make(map[string]*Agent),
 		publicCards:    make([]*Card, 0, 5),
@@ -147,12 +195,39 @@ func NewHoldem(
 		seatCount:      sc,
 		sb:             sb,
 		nextSb:         -1,
+		nextBb:         -1,
+		bb:             sb * 2,
 		ante:           exts.ante,
 		nextAnte:       -1,
 		log:            log,
 		nextGame:       nextGame,
 		payToPlayMap:   payMap,
 		options:        exts,
+		gameStatusCh:   make(chan int8),
+		ledger:         newLedger(),
+		departures:     make(map[string]*departure),
+		reserved:       make(map[int8]string),
+	}
+	if exts.sitAndGo {
+		h.sng = newSitAndGo(exts.prizePool, exts.payouts)
+	}
+	if exts.provablyFair {
+		h.fairNext = newFairHand(1, poker.deck)
+	}
+	if exts.bounty > 0 {
+		h.bounty = &bountyState{
+			amount:      exts.bounty,
+			progressive: exts.progressiveBounty,
+			bounties:    make(map[string]uint),
+		}
+	}
+	if exts.rebuyPolicy != nil {
+		h.rebuy = newRebuyState(exts.rebuyPolicy)
+	}
+	if len(exts.blindSchedule) > 0 {
+		h.blinds = &blindState{
+			schedule: exts.blindSchedule,
+		}
 	}
 	go h.gameLoop()
 	return h
@@ -167,6 +242,9 @@ func (c *Holdem) join(rs *Agent) {
 		oldRs.replace(rs)
 		c.roomers[rs.ID()] = oldRs
 		oldRs.recv.PlayerJoinSuccess(c.id, rs.ID(), c.information(oldRs))
+		if c.fairNext != nil {
+			oldRs.recv.RoomerGetFairCommitment(c.id, c.fairNext.commitment())
+		}
 		return
 	}
 	c.roomers[rs.ID()] = rs
@@ -176,6 +254,9 @@ func (c *Holdem) join(rs *Agent) {
 		}
 	}
 	rs.recv.PlayerJoinSuccess(c.id, rs.ID(), c.information(rs))
+	if c.fairNext != nil {
+		rs.recv.RoomerGetFairCommitment(c.id, c.fairNext.commitment())
+	}
 }
 
 //leave 离开
@@ -193,16 +274,20 @@ func (c *Holdem) leave(rs *Agent) {
 
 //Seated 坐下
 func (c *Holdem) seated(i int8, r *Agent) {
-	if r.gameInfo == nil || r.gameInfo.chip < c.ante+c.sb*2 {
+	if r.gameInfo == nil || r.gameInfo.chip == 0 || (!c.options.allowShortStack && r.gameInfo.chip < c.ante+c.bb) {
 		r.recv.ErrorOccur(c.id, ErrCodeNoChip, errNoChip)
 		return
 	}
+	if c.options.minBuyIn > 0 && r.gameInfo.chip < c.options.minBuyIn*c.bb {
+		r.recv.ErrorOccur(c.id, ErrCodeBuyInTooLittle, errBuyInTooLittle)
+		return
+	}
 	c.seatLock.Lock()
 	//自动找座
 	if i == 0 {
 		var idx int8 = 1
 		for ; idx <= c.seatCount; idx++ {
-			if _, ok := c.players[idx]; !ok {
+			if _, ok := c.players[idx]; !ok && c.reserved[idx] == "" {
 				i = idx
 			}
 		}
@@ -212,16 +297,30 @@ func (c *Holdem) seated(i int8, r *Agent) {
 			return
 		}
 	} else {
-		if c.players[i] != nil {
+		if c.players[i] != nil || c.reserved[i] != "" {
 			c.seatLock.Unlock()
 			r.recv.ErrorOccur(c.id, ErrCodeSeatTaken, errSeatTaken)
 			return
 		}
 	}
+	if err := c.checkRathole(r); err != nil {
+		c.seatLock.Unlock()
+		r.recv.ErrorOccur(c.id, err.code, err.err)
+		return
+	}
+	c.sitDown(i, r)
+	info := c.information()
+	c.seatLock.Unlock()
+	c.autoStart(info)
+}
+
+//sitDown 坐到空座位上并通知(有锁)
+func (c *Holdem) sitDown(i int8, r *Agent) {
 	r.gameInfo.seatNumber = i
 	r.gameInfo.te = PlayTypeNormal
 	c.players[i] = r
 	c.playerCount++
+	c.transferChip(playerAccount(r.id), accountCashier, r.gameInfo.chip, "bring in")
 	//开启补盲
 	r.gameInfo.te = c.payToPlayMap[i]
 	c.log.Debug("user seated", zap.Int8("seat", i), zap.String("na", c.players[i].ID()), zap.Int8("te", int8(r.gameInfo.te)))
@@ -233,8 +332,10 @@ func (c *Holdem) seated(i int8, r *Agent) {
 			rr.recv.RoomerSeated(c.id, i, r.id, r.gameInfo.te)
 		}
 	}
-	info := c.information()
-	c.seatLock.Unlock()
+}
+
+//autoStart 人数够了自动开始
+func (c *Holdem) autoStart(info *HoldemState) {
 	if c.status() == GameStatusNotStart && c.options.autoStart && c.playerCount >= c.options.autoMinPlayers {
 		if ok := c.nextGame(info); ok {
 			c.Start()
@@ -271,12 +372,17 @@ func (c *Holdem) delayStandUp(i int8, r *Agent, tm time.Duration, reason int8) {
 		if c.status() == GameStatusComplete || c.status() == GameStatusCancel {
 			return
 		}
+		c.seatLock.Lock()
+		defer c.seatLock.Unlock()
 		//还是空筹码
-		if r.gameInfo.chip == 0 && r.gameInfo.seatNumber == i {
+		if r.gameInfo != nil && r.gameInfo.chip == 0 && r.gameInfo.pendingChip == 0 && r.gameInfo.seatNumber == i {
 			c.log.Debug("less chip auto stand up", zap.Int8("seat", i), zap.String("user", r.ID()))
-			c.seatLock.Lock()
+			if c.sng != nil {
+				//单桌赛淘汰
+				c.sngEliminate([]*Agent{r})
+				return
+			}
 			c.standUp(i, r, reason)
-			c.seatLock.Unlock()
 		}
 	})
 }
@@ -284,6 +390,12 @@ func (c *Holdem) delayStandUp(i int8, r *Agent, tm time.Duration, reason int8) {
 //standUp 站起来
 func (c *Holdem) standUp(i int8, r *Agent, reason int8) {
 	//c.log.Debug("standup", zap.Int8("seat", i), zap.Bool("fake", r.fake), zap.String("na", c.players[i].ID()), zap.Int8("te", int8(r.gameInfo.te)))
+	//站起前把等待中的筹码加上,换桌/带出/结算都包含这部分
+	c.applyPendingChip(r)
+	c.queueTransfer(r)
+	c.transferChip(accountCashier, playerAccount(r.id), r.gameInfo.chip, "cash out")
+	c.recordDeparture(r)
+	c.settle(i, r, reason)
 	r.gameInfo = nil
 	delete(c.players, i)
 	c.playerCount--
@@ -303,6 +415,11 @@ func (c *Holdem) status() int8 {
 	return int8(v)
 }
 
+//stopped 是否已经要求结束
+func (c *Holdem) stopped() bool {
+	return atomic.LoadInt32(&c.stopLock) == 1
+}
+
 //statusChange 状态改变
 func (c *Holdem) statusChange(status int8) {
 	atomic.StoreInt32(&c.gameStartedLock, int32(status))
@@ -315,7 +432,7 @@ func (c *Holdem) base() *HoldemBase {
 		Ante:                c.ante,
 		SmallBlind:          c.sb,
 		SBSeat:              c.sbSeat,
-		BigBlind:            c.sb * 2,
+		BigBlind:            c.bb,
 		BBSeat:              c.bbSeat,
 		SeatCount:           c.seatCount,
 		ButtonSeat:          c.buttonSeat,
@@ -343,7 +460,7 @@ func (c *Holdem) information(rs ...*Agent) *HoldemState {
 		if ok {
 			_, ok2 := rMap[p]
 			players = append(players, p.displayUser(ok2))
-		} else {
+		} else if c.reserved[s] == "" {
 			emptySeats = append(emptySeats, s)
 		}
 	}
@@ -353,9 +470,12 @@ func (c *Holdem) information(rs ...*Agent) *HoldemState {
 		EmptySeats:  emptySeats,
 		Pot:         c.pot,
 		PublicCards: c.publicCards,
+		SecondBoard: c.secondBoard,
 		Onlines:     uint(len(c.roomers)),
 		Insurance:   c.insuranceInformation,
 		Paused:      c.paused,
+		Rake:        c.rake,
+		JackpotDrop: c.jackpotDropped,
 	}
 }
 
@@ -415,6 +535,11 @@ func (c *Holdem) buttonPosition() bool {
 			cur = p
 		}
 	}
+	//没有可以游戏的玩家(都换桌走了或者没有筹码)
+	if newButton == nil {
+		c.log.Debug("button position end(no player)", zap.Int8("seat count", c.playerCount))
+		return false
+	}
 	newButton.prevAgent = cur
 	cur.nextAgent = newButton
 	//坐着的人比约定人数少 不开始比赛也不轮转
@@ -468,6 +593,7 @@ func (c *Holdem) buttonPosition() bool {
 	c.handStartInfo = &StartNewHandInfo{
 		AnteAllIns: []int8{},
 		PayToPlay:  []int8{},
+		Straddles:  []*StraddleBet{},
 		SBSeat:     c.sbSeat,
 		BBSeat:     c.bbSeat,
 	}
@@ -502,6 +628,7 @@ func (c *Holdem) checkRoundComplete() (bool, []*Agent, bool) {
 	u := c.button
 	users := make([]*Agent, 0)
 	allInCount := 0
+	notActed := 0
 	for {
 		//已盖牌/未发牌玩家跳过
 		if u.fake {
@@ -525,14 +652,18 @@ func (c *Holdem) checkRoundComplete() (bool, []*Agent, bool) {
 		if c.roundBet > 0 && u.gameInfo.roundBet != c.roundBet {
 			return false, nil, false
 		}
+		//本轮还未行动(包括大盲/抓头的option)
+		if !u.gameInfo.acted {
+			notActed++
+		}
 		users = append(users, u)
 		u = u.nextAgent
 		if u == c.button {
 			break
 		}
 	}
-	//还没有下注但是玩家大于1,还未结束
-	if c.roundBet == 0 && len(users) > 1 {
+	//还有人未行动,并且还有其他可以行动的玩家,还未结束
+	if notActed > 0 && len(users)-allInCount > 1 {
 		return false, nil, false
 	}
 	return true, users, allInCount > 0 && len(users) > 1 && allInCount >= len(users)-1
@@ -28,8 +28,10 @@ func (c *Holdem) Pause() {
 
 //Resume 继续
 func (c *Holdem) Resume() {
-	close(c.pauseCh)
-	c.paused = false
+	if c.paused {
+		close(c.pauseCh)
+		c.paused = false
+	}
 }
 
 //Cancel 提前取消
@@ -42,6 +44,11 @@ func (c *Holdem) Cancel() {
 	}
 }
 
+//Stop 结束游戏(当前一手结束后,不再开始下一手)
+func (c *Holdem) Stop() {
+	atomic.StoreInt32(&c.stopLock, 1)
+}
+
 //ID 游戏标识
 func (c *Holdem) ID() string {
 	return c.id
@@ -57,11 +64,19 @@ func (c *Holdem) State(rs ...*Agent) *HoldemState {
 //ChangeBetConfig 修改下注配置（小盲/前注)
 func (c *Holdem) ChangeBetConfig(sb uint, ante ...uint) {
 	c.nextSb = int(sb)
+	c.nextBb = -1
 	if len(ante) > 0 {
 		c.nextAnte = int(ante[0])
 	}
 }
 
+//ChangeBlindLevel 按盲注级别修改小盲/大盲/前注(下一手开始生效)
+func (c *Holdem) ChangeBlindLevel(lv *BlindLevel) {
+	c.nextSb = int(lv.SmallBlind)
+	c.nextBb = int(lv.bigBlind())
+	c.nextAnte = int(lv.Ante)
+}
+
 //ForceStandUp 强制让人站起
 func (c *Holdem) ForceStandUp(id ...string) {
 	c.seatLock.Lock()
@@ -95,7 +110,8 @@ func (c *Holdem) ForcePlayerStandUp(count uint8) {
 	for i = 0; i < c.seatCount; i++ {
 		seat := ((i + buIdx - 1) % c.seatCount) + 1
 		r, ok := c.players[seat]
-		if ok {
+		//没有筹码的玩家会自行站起
+		if ok && r.gameInfo.chip > 0 {
 			r.gameInfo.needStandUpReason = StandUpGameExchange
 			if r.gameInfo.status == ActionDefNone {
 				c.standUp(seat, r, StandUpGameExchange)
@@ -6,8 +6,9 @@ import (
 	"go.uber.org/zap"
 )
 
-//doAnte 前注
-func (c *Holdem) doAnte() {
+//doAnte 前注(炸弹底池也用前注的方式下注)
+//前注计入handBet,按下注分层计算主边池时才不会漏掉前注(全下的前注只参与到自己投入的那一层)
+func (c *Holdem) doAnte(ante uint) {
 	u := c.button
 	for {
 		if u.gameInfo.te == PlayTypeDisable || u.gameInfo.te == PlayTypeNeedPayToPlay {
@@ -17,12 +18,14 @@ func (c *Holdem) doAnte() {
 			}
 			continue
 		}
-		if u.gameInfo.chip >= c.ante {
-			c.pot += c.ante
-			u.gameInfo.chip -= c.ante
+		if u.gameInfo.chip >= ante {
+			c.pot += ante
+			u.gameInfo.handBet += ante
+			u.gameInfo.chip -= ante
+			c.transferChip(accountPot, playerAccount(u.id), ante, "ante")
 			u.gameInfo.status = ActionDefAnte
-			c.options.recorder.Ante(c.base(), u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, c.ante)
-			c.log.Debug("ante", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", c.ante))
+			c.options.recorder.Ante(c.base(), u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ante)
+			c.log.Debug("ante", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", ante))
 			u = u.nextAgent
 			if u == c.button {
 				break
@@ -31,10 +34,12 @@ func (c *Holdem) doAnte() {
 		}
 		c.handStartInfo.AnteAllIns = append(c.handStartInfo.AnteAllIns, u.gameInfo.seatNumber)
 		c.pot += u.gameInfo.chip
+		u.gameInfo.handBet += u.gameInfo.chip
 		c.options.recorder.Ante(c.base(), u.gameInfo.seatNumber, u.ID(), 0, u.gameInfo.chip)
+		c.log.Debug("ante", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", u.gameInfo.chip))
+		c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.chip, "ante")
 		u.gameInfo.chip = 0
 		u.gameInfo.status = ActionDefAllIn
-		c.log.Debug("ante", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", u.gameInfo.chip))
 		u = u.nextAgent
 		if u == c.button {
 			break
@@ -58,6 +63,7 @@ func (c *Holdem) smallBlind() {
 		u.gameInfo.roundBet = c.sb
 		u.gameInfo.handBet += u.gameInfo.roundBet
 		u.gameInfo.chip -= u.gameInfo.roundBet
+		c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.roundBet, "small blind")
 		u.gameInfo.status = ActionDefSB
 		c.handStartInfo.SB = &Bet{
 			Action: ActionDefSB,
@@ -72,6 +78,7 @@ func (c *Holdem) smallBlind() {
 	u.gameInfo.roundBet = u.gameInfo.chip
 	u.gameInfo.handBet += u.gameInfo.roundBet
 	u.gameInfo.chip -= u.gameInfo.roundBet
+	c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.roundBet, "small blind")
 	u.gameInfo.status = ActionDefAllIn
 	c.handStartInfo.SB = &Bet{
 		Action: ActionDefAllIn,
@@ -88,18 +95,19 @@ func (c *Holdem) bigBlind() {
 		c.log.Debug("big blind", zap.Int8("seat", u.gameInfo.seatNumber), zap.Int("allin", 0))
 		return
 	}
-	if u.gameInfo.chip >= 2*c.sb {
-		c.pot += c.sb * 2
-		u.gameInfo.roundBet = c.sb * 2
+	if u.gameInfo.chip >= c.bb {
+		c.pot += c.bb
+		u.gameInfo.roundBet = c.bb
 		u.gameInfo.handBet += u.gameInfo.roundBet
 		u.gameInfo.chip -= u.gameInfo.roundBet
+		c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.roundBet, "big blind")
 		u.gameInfo.status = ActionDefBB
 		c.handStartInfo.BB = &Bet{
 			Action: ActionDefBB,
-			Num:    c.sb * 2,
+			Num:    c.bb,
 		}
-		c.options.recorder.Action(c.base(), RoundPreFlop, u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ActionDefBB, 2*c.sb)
-		c.log.Debug("big blind", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", c.sb*2))
+		c.options.recorder.Action(c.base(), RoundPreFlop, u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ActionDefBB, c.bb)
+		c.log.Debug("big blind", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", c.bb))
 		return
 	}
 	//不够时 全下
@@ -107,6 +115,7 @@ func (c *Holdem) bigBlind() {
 	u.gameInfo.roundBet = u.gameInfo.chip
 	u.gameInfo.handBet += u.gameInfo.roundBet
 	u.gameInfo.chip -= u.gameInfo.roundBet
+	c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.roundBet, "big blind")
 	u.gameInfo.status = ActionDefAllIn
 	c.handStartInfo.BB = &Bet{
 		Action: ActionDefAllIn,
@@ -120,16 +129,17 @@ func (c *Holdem) payToPlay() {
 	u := c.button
 	for {
 		if u.gameInfo.te == PlayTypeAgreePayToPlay {
-			c.pot += c.sb * 2
-			u.gameInfo.roundBet = c.sb * 2
+			c.pot += c.bb
+			u.gameInfo.roundBet = c.bb
 			u.gameInfo.handBet += u.gameInfo.roundBet
 			u.gameInfo.chip -= u.gameInfo.roundBet
+			c.transferChip(accountPot, playerAccount(u.id), u.gameInfo.roundBet, "pay to play")
 			u.gameInfo.status = ActionDefBB
 			u.gameInfo.te = PlayTypeNormal
 			c.handStartInfo.PayToPlay = append(c.handStartInfo.PayToPlay, u.gameInfo.seatNumber)
 			//补盲
-			c.options.recorder.Action(c.base(), RoundPreFlop, u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ActionDefBB, 2*c.sb)
-			c.log.Debug("pay to play", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", c.sb*2))
+			c.options.recorder.Action(c.base(), RoundPreFlop, u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ActionDefBB, c.bb)
+			c.log.Debug("pay to play", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", c.bb))
 		}
 		u = u.nextAgent
 		if u == c.button {
@@ -138,10 +148,81 @@ func (c *Holdem) payToPlay() {
 	}
 }
 
+//straddle 抓头(大盲下家开始连续翻倍下注,或者庄位抓头)
+func (c *Holdem) straddle() {
+	c.straddler = nil
+	if c.options.maxStraddles <= 0 {
+		return
+	}
+	sb := c.button.nextAgent
+	bb := sb.nextAgent
+	u := c.getNextOpAgent(bb)
+	//庄位抓头(Mississippi)
+	onButton := false
+	if c.options.buttonStraddle && !c.button.fake && c.button.gameInfo.straddle && c.button.gameInfo.status != ActionDefAllIn {
+		u = c.button
+		onButton = true
+	}
+	amount := c.bb * 2
+	var times int8
+	for u != nil && times < c.options.maxStraddles {
+		//大小盲不能抓头,抓头必须连续
+		if u == sb || u == bb || !u.gameInfo.straddle || u.gameInfo.chip <= amount {
+			break
+		}
+		c.pot += amount
+		u.gameInfo.roundBet = amount
+		u.gameInfo.handBet += amount
+		u.gameInfo.chip -= amount
+		c.transferChip(accountPot, playerAccount(u.id), amount, "straddle")
+		u.gameInfo.status = ActionDefStraddle
+		c.handStartInfo.Straddles = append(c.handStartInfo.Straddles, &StraddleBet{
+			SeatNumber: u.gameInfo.seatNumber,
+			Bet: &Bet{
+				Action: ActionDefStraddle,
+				Num:    amount,
+			},
+		})
+		c.options.recorder.Action(c.base(), RoundPreFlop, u.gameInfo.seatNumber, u.ID(), u.gameInfo.chip, ActionDefStraddle, amount)
+		c.log.Debug("straddle", zap.Int8("seat", u.gameInfo.seatNumber), zap.Uint("amount", amount))
+		c.roundBet = amount
+		c.minRaise = amount
+		c.raiseTimes++
+		c.straddler = u
+		times++
+		//庄位抓头后是小盲,不能继续
+		if onButton {
+			break
+		}
+		amount *= 2
+		u = c.getNextOpAgent(u)
+	}
+	//抓头声明只对本手有效
+	u = c.button
+	for {
+		u.gameInfo.straddle = false
+		u = u.nextAgent
+		if u == c.button {
+			break
+		}
+	}
+}
+
+//firstPreflopAgent 翻牌前第一个行动的人(大盲/最后一个抓头的下家,庄位抓头从小盲开始)
+func (c *Holdem) firstPreflopAgent() *Agent {
+	if c.straddler == nil {
+		return c.getNextOpAgent(c.button.nextAgent.nextAgent)
+	}
+	if c.straddler == c.button {
+		return c.getNextOpAgent(c.button)
+	}
+	return c.getNextOpAgent(c.straddler)
+}
+
 //deal 发牌
 func (c *Holdem) deal() *Agent {
-	cnt := 2
-	c.log.Debug("deal begin", zap.Int("cards_count", 2))
+	cnt := c.options.holeCards
+	c.log.Debug("deal begin", zap.Int("cards_count", cnt))
 	first := c.button.nextAgent
 	cards := make([][]*Card, c.playingPlayerCount)
 	max := cnt
@@ -155,8 +236,12 @@ func (c *Holdem) deal() *Agent {
 		}
 	}
 	cur := first
-	firstAg := c.getNextOpAgent(c.button.nextAgent.nextAgent)
-	op := newOperator(firstAg, 2*c.sb, 2*c.sb, c.waitBetTimeout)
+	var firstAg *Agent
+	//炸弹底池没有翻牌前下注
+	if c.bombPot == nil {
+		firstAg = c.firstPreflopAgent()
+	}
+	op := c.newOperator(firstAg, RoundPreFlop)
 	if firstAg != nil {
 		firstAg.enableBet(true)
 	}
@@ -192,8 +277,6 @@ func (c *Holdem) deal() *Agent {
 //preflop 翻牌前叫注
 func (c *Holdem) preflop(op *Agent) ([]*Agent, bool) {
 	c.statusChange(GameStatusHandPreflop)
-	c.roundBet = c.sb * 2
-	c.minRaise = c.sb * 2
 	u := op
 	var roundComplete, showcard bool
 	var unfoldUsers []*Agent
@@ -201,7 +284,8 @@ func (c *Holdem) preflop(op *Agent) ([]*Agent, bool) {
 	for u != nil {
 		c.waitPause()
 		c.log.Debug("wait bet", zap.Int8("seat", u.gameInfo.seatNumber), zap.String("status", u.gameInfo.status.String()), zap.String("round", RoundPreFlop.String()))
-		bet := u.waitBet(c.roundBet, c.minRaise, RoundPreFlop, c.waitBetTimeout+delaySend)
+		bet := u.waitBet(c.betSituation(u, RoundPreFlop), c.waitBetTimeout+delaySend)
+		u.gameInfo.acted = true
 		switch bet.Action {
 		case ActionDefFold:
 			//盖牌的直接移除出局
@@ -216,18 +300,21 @@ func (c *Holdem) preflop(op *Agent) ([]*Agent, bool) {
 				c.seatLock.Unlock()
 			}
 			u = u2
+		case ActionDefCheck:
 		case ActionDefCall:
 			c.pot += bet.Num
 		case ActionDefRaise:
 			c.pot += bet.Num
 			c.minRaise = u.gameInfo.roundBet - c.roundBet //当轮下注额度 - 目前这轮最高下注额
 			c.roundBet = u.gameInfo.roundBet              //更新最高下注额
+			c.raiseTimes++
 		case ActionDefAllIn:
 			c.pot += bet.Num
 			raise := u.gameInfo.roundBet - c.roundBet
 			//如果加注大于最小加注 视为raise,否则视为call
 			if raise >= c.minRaise {
 				c.minRaise = raise
+				c.raiseTimes++
 			}
 			//大于本轮最大下注时候才更新本轮最大
 			if u.gameInfo.roundBet > c.roundBet {
@@ -243,7 +330,7 @@ func (c *Holdem) preflop(op *Agent) ([]*Agent, bool) {
 		var op *Operator
 		if !roundComplete {
 			next = c.getNextOpAgent(u)
-			op = newOperator(next, c.roundBet, c.minRaise, c.waitBetTimeout)
+			op = c.newOperator(next, RoundPreFlop)
 			if next != nil {
 				next.enableBet(true)
 			}
@@ -288,8 +375,24 @@ func (c *Holdem) dealPublicCards(n int, round Round) ([]*Card, *Agent) {
 	_, _ = c.poker.GetCards(1)
 	cards, _ := c.poker.GetCards(n)
 	c.publicCards = append(c.publicCards, cards...)
+	if c.doubleBoard() {
+		c.dealSecondBoard(n)
+	}
+	//清理此轮
+	c.roundBet = 0
+	c.minRaise = c.bb
+	c.raiseTimes = 0
+	uu := c.button
+	for {
+		uu.gameInfo.roundBet = 0
+		uu.gameInfo.acted = false
+		uu = uu.nextAgent
+		if uu == c.button {
+			break
+		}
+	}
 	firstAg := c.getNextOpAgent(c.button)
-	firstOp := newOperator(firstAg, 0, 2*c.sb, c.waitBetTimeout)
+	firstOp := c.newOperator(firstAg, round)
 	c.addWaitTime(c.waitBetTimeout)
 	if firstAg != nil {
 		firstAg.enableBet(true)
@@ -313,24 +416,14 @@ func (c *Holdem) flopTurnRiver(u *Agent, round Round) ([]*Agent, bool) {
 	case RoundRiver:
 		c.statusChange(GameStatusHandRiver)
 	}
-	c.roundBet = 0
-	c.minRaise = c.sb * 2
 	var roundComplete, showcard bool
 	var unfoldUsers []*Agent
-	//清理此轮
-	uu := c.button
-	for {
-		uu.gameInfo.roundBet = 0
-		uu = uu.nextAgent
-		if uu == c.button {
-			break
-		}
-	}
 	c.log.Debug(round.String()+" bet begin", zap.Int8("pc", c.playingPlayerCount), zap.Int8("sseat", u.gameInfo.seatNumber), zap.String("suser", u.ID()))
 	for u != nil {
 		c.waitPause()
 		c.log.Debug("wait bet", zap.Int8("seat", u.gameInfo.seatNumber), zap.String("status", u.gameInfo.status.String()), zap.String("round", round.String()))
-		bet := u.waitBet(c.roundBet, c.minRaise, round, c.waitBetTimeout+delaySend)
+		bet := u.waitBet(c.betSituation(u, round), c.waitBetTimeout+delaySend)
+		u.gameInfo.acted = true
 		switch bet.Action {
 		case ActionDefFold:
 			//盖牌的直接移除出局
@@ -349,18 +442,21 @@ func (c *Holdem) flopTurnRiver(u *Agent, round Round) ([]*Agent, bool) {
 		case ActionDefBet:
 			c.pot += bet.Num
 			c.roundBet = bet.Num
+			c.raiseTimes++
 		case ActionDefCall:
 			c.pot += bet.Num
 		case ActionDefRaise:
 			c.pot += bet.Num
 			c.minRaise = u.gameInfo.roundBet - c.roundBet //当轮下注额度 - 目前这轮最高下注额
 			c.roundBet = u.gameInfo.roundBet              //更新最高下注额
+			c.raiseTimes++
 		case ActionDefAllIn:
 			c.pot += bet.Num
 			raise := u.gameInfo.roundBet - c.roundBet
 			//如果加注大于最小加注 视为raise,否则视为call
 			if raise >= c.minRaise {
 				c.minRaise = raise
+				c.raiseTimes++
 			}
 			//大于本轮最大下注时候才更新本轮最大
 			if u.gameInfo.roundBet > c.roundBet {
@@ -376,7 +472,7 @@ func (c *Holdem) flopTurnRiver(u *Agent, round Round) ([]*Agent, bool) {
 		var op *Operator
 		if !roundComplete {
 			next = c.getNextOpAgent(u)
-			op = newOperator(next, c.roundBet, c.minRaise, c.waitBetTimeout)
+			op = c.newOperator(next, round)
 			if next != nil {
 				next.enableBet(true)
 			}
@@ -413,11 +509,107 @@ func (c *Holdem) flopTurnRiver(u *Agent, round Round) ([]*Agent, bool) {
 	return unfoldUsers, showcard
 }
 
-//complexWin 斗牌结算
-func (c *Holdem) complexWin(users []*Agent) {
+//complexWin 斗牌结算(多次发牌时每个池按次数平分,零头给第一次)
+func (c *Holdem) complexWin(users []*Agent, boards ...[]*Card) {
+	if len(boards) == 0 {
+		boards = [][]*Card{c.publicCards}
+	}
 	pots := c.calcPot(users)
-	results, _, _ := c.calcWin(users, pots)
+	//分配前抽水和注入奖池
+	rakes := c.takeRake(pots)
+	for _, rake := range rakes {
+		c.transferChip(accountRake, accountPot, rake, "rake")
+	}
+	if len(pots[0].SeatNumber) > 1 {
+		drop := c.jackpotDrop(pots[0].Num)
+		pots[0].Num -= drop
+		c.transferChip(accountJackpot, accountPot, drop, "jackpot drop")
+	}
+	times := uint(len(boards))
+	results := make(map[int8]*Result)
+	runs := make(map[int8][]*RunResult)
+	var first map[int8]*HandValue
+	var firstCards map[int8][]*CardResult
+	potWins := make([]map[int8]uint, len(pots))
+	for j := range potWins {
+		potWins[j] = make(map[int8]uint)
+	}
+	for i, board := range boards {
+		runPots := make([]*Pot, 0, len(pots))
+		for _, pot := range pots {
+			num := pot.Num / times
+			if i == 0 {
+				num += pot.Num - num*times
+			}
+			runPots = append(runPots, &Pot{
+				SeatNumber: pot.SeatNumber,
+				Num:        num,
+			})
+		}
+		//每次发牌重新计算牌型
+		for _, u := range users {
+			u.gameInfo.handValue = nil
+		}
+		//按池分别计算(赏金归淘汰者所在池的赢家)
+		rs := make(map[int8]*Result)
+		for j, pot := range runPots {
+			prs, _, _ := c.calcWin(users, []*Pot{pot}, board)
+			for seat, rv := range prs {
+				potWins[j][seat] += rv.Num
+				if v, ok := rs[seat]; ok {
+					v.Num += rv.Num
+					continue
+				}
+				rs[seat] = rv
+			}
+		}
+		if i == 0 {
+			first = make(map[int8]*HandValue)
+			firstCards = make(map[int8][]*CardResult)
+		}
+		for _, u := range users {
+			seat := u.gameInfo.seatNumber
+			if i == 0 {
+				first[seat] = u.gameInfo.handValue
+				firstCards[seat] = u.gameInfo.cardResults
+			}
+			if times == 1 {
+				continue
+			}
+			rr := &RunResult{
+				Run:           int8(i + 1),
+				PublicCards:   board,
+				Cards:         u.gameInfo.cardResults,
+				HandValueType: u.gameInfo.handValue.MaxHandValueType(),
+			}
+			if rv, ok := rs[seat]; ok {
+				rr.Num = rv.Num
+			}
+			runs[seat] = append(runs[seat], rr)
+		}
+		for seat, rv := range rs {
+			if v, ok := results[seat]; ok {
+				v.Num += rv.Num
+				continue
+			}
+			results[seat] = rv
+		}
+	}
+	//主结果以第一次发牌为准
+	c.publicCards = boards[0]
+	for _, u := range users {
+		u.gameInfo.handValue = first[u.gameInfo.seatNumber]
+		u.gameInfo.cardResults = firstCards[u.gameInfo.seatNumber]
+	}
 	c.pot = 0
+	shares := c.rakeShares(rakes, potWins)
+	//坏牌奖池(只看一次发牌)
+	var hit *JackpotHit
+	var jackpots map[int8]uint
+	if len(boards) == 1 {
+		hit, jackpots = c.checkJackpot(users, potWins[0])
+	}
+	highHands := c.checkHighHands(users)
 	ret := make([]*Result, 0)
 	u := c.button
 	//所有玩家的最终状况
@@ -431,8 +623,12 @@ func (c *Holdem) complexWin(users []*Agent) {
 		}
 		if rv, ok := results[u.gameInfo.seatNumber]; ok {
 			u.gameInfo.chip += rv.Num
+			c.transferChip(playerAccount(u.id), accountPot, rv.Num, "win")
 			r.Num = rv.Num
 		}
+		r.Runs = runs[u.gameInfo.seatNumber]
+		r.Rake = shares[u.gameInfo.seatNumber]
+		r.Jackpot = jackpots[u.gameInfo.seatNumber]
 		//保险
 		if iv, ok := c.insuranceResult[u.gameInfo.seatNumber]; ok {
 			r.InsuranceResult = iv
@@ -445,17 +641,47 @@ func (c *Holdem) complexWin(users []*Agent) {
 		}
 	}
 	c.seatLock.Lock()
+	//赏金
+	bounties := c.awardBounties(users, pots, potWins)
+	if len(bounties) > 0 {
+		awards := make([]*BountyAward, 0)
+		for _, r := range ret {
+			r.Bounties = bounties[r.SeatNumber]
+			awards = append(awards, r.Bounties...)
+		}
+		c.options.recorder.Bounty(c.base(), awards)
+	}
+	if hit != nil {
+		c.options.recorder.Jackpot(c.base(), hit)
+		for _, r := range c.roomers {
+			r.recv.RoomerGetJackpot(c.id, hit)
+		}
+	}
+	if len(highHands) > 0 {
+		c.options.recorder.HighHand(c.base(), highHands)
+		for _, r := range c.roomers {
+			r.recv.RoomerGetHighHand(c.id, highHands)
+		}
+	}
 	for _, r := range c.roomers {
 		r.recv.RoomerGetResult(c.id, ret)
 	}
 	c.statusChange(GameStatusHandEnd)
 	c.options.recorder.HandEnd(c.information(), ret)
+	c.revealFair()
 	c.seatLock.Unlock()
 	c.log.Debug("cwin", zap.Any("result", ret))
 }
 
 //simpleWin 单人获胜（只有一人未盖牌)
 func (c *Holdem) simpleWin(agent *Agent) {
+	c.keepRabbitCards()
+	//分配前抽水和注入奖池
+	called := c.calledPot(agent)
+	rake := c.simpleRake(called)
+	drop := c.jackpotDrop(called - rake)
+	c.transferChip(accountRake, accountPot, rake, "rake")
+	c.transferChip(accountJackpot, accountPot, drop, "jackpot drop")
 	ret := make([]*Result, 0)
 	u := c.button
 	for {
@@ -464,8 +690,11 @@ func (c *Holdem) simpleWin(agent *Agent) {
 			Te:         u.gameInfo.te,
 		}
 		if u.gameInfo.seatNumber == agent.gameInfo.seatNumber {
-			u.gameInfo.chip += c.pot
-			r.Num = c.pot
+			win := c.pot - rake - drop
+			u.gameInfo.chip += win
+			c.transferChip(playerAccount(u.id), accountPot, win, "win")
+			r.Num = win
+			r.Rake = rake
 			c.pot = 0
 		}
 		//保险
@@ -485,6 +714,7 @@ func (c *Holdem) simpleWin(agent *Agent) {
 	}
 	c.statusChange(GameStatusHandEnd)
 	c.options.recorder.HandEnd(c.information(), ret)
+	c.revealFair()
 	c.seatLock.Unlock()
 	c.log.Debug("swin", zap.Int8("seat", agent.gameInfo.seatNumber), zap.String("user", agent.ID()), zap.Any("result", ret))
 }
@@ -494,25 +724,60 @@ func (c *Holdem) startHand() {
 	c.waitPause()
 	c.statusChange(GameStatusHandStartd)
 	c.pot = 0
-	if c.ante > 0 {
+	c.rake = 0
+	c.jackpotDropped = 0
+	c.runVoted = false
+	c.clearRabbitCards()
+	c.handStartInfo.BombPot = c.bombPot
+	if c.bombPot != nil {
+		//炸弹底池
+		c.doAnte(c.bombPot.Amount)
+	} else if c.ante > 0 {
 		//前注
-		c.doAnte()
+		c.doAnte(c.ante)
 	}
 	c.publicCards = c.publicCards[:0]
+	c.secondBoard = nil
 	//洗牌
-	c.poker.Reset()
-	//下盲注
-	c.smallBlind()
-	c.bigBlind()
-	//补盲
-	if c.options.isPayToPlay {
-		c.payToPlay()
-	}
-	//发牌（返回第一个行动的人）
-	c.waitPause()
-	firstAg := c.deal()
-	//翻牌前下注
-	users, showcard := c.preflop(firstAg)
+	if c.options.provablyFair {
+		c.fairShuffle()
+	} else {
+		c.poker.Reset()
+		c.recordEntropy()
+	}
+	var users []*Agent
+	var showcard bool
+	if c.bombPot == nil {
+		//下盲注
+		c.smallBlind()
+		c.bigBlind()
+		//补盲
+		if c.options.isPayToPlay {
+			c.payToPlay()
+		}
+		//翻牌前大盲为第一次下注
+		c.roundBet = c.bb
+		c.minRaise = c.bb
+		c.raiseTimes = 1
+		//抓头
+		c.straddle()
+		//发牌（返回第一个行动的人）
+		c.waitPause()
+		firstAg := c.deal()
+		if firstAg == nil {
+			//盲注和前注后没有可以行动的玩家(都已全下)直接比牌
+			users, showcard = c.bombPotUsers()
+		} else {
+			//翻牌前下注
+			users, showcard = c.preflop(firstAg)
+		}
+	} else {
+		//炸弹底池发牌后直接到翻牌
+		c.straddler = nil
+		c.waitPause()
+		c.deal()
+		users, showcard = c.bombPotUsers()
+	}
 	//如果只有一个人翻牌游戏结束
 	if len(users) == 1 {
 		c.simpleWin(users[0])
@@ -520,8 +785,12 @@ func (c *Holdem) startHand() {
 	}
 	//广播主边池内容
 	c.sendPotsInfo(users, RoundPreFlop)
+	//已亮牌可以选择多次发牌
+	if showcard && c.runMultiple(users) {
+		return
+	}
 	//洗牌,并发送3张公共牌
-	_, firstAg = c.dealPublicCards(3, RoundFlop)
+	_, firstAg := c.dealPublicCards(3, RoundFlop)
 	//未亮牌要下注
 	if !showcard {
 		//翻牌轮下注
@@ -534,8 +803,12 @@ func (c *Holdem) startHand() {
 	}
 	//广播主边池内容
 	c.sendPotsInfo(users, RoundFlop)
+	//已亮牌可以选择多次发牌
+	if showcard && c.runMultiple(users) {
+		return
+	}
 	//已亮牌并且有保险开始保险逻辑
-	if showcard && c.options.insuranceOpen {
+	if showcard && c.options.insuranceOpen && !c.doubleBoard() {
 		//等待买保险
 		c.insuranceStart(users, RoundFlop)
 	}
@@ -543,7 +816,7 @@ func (c *Holdem) startHand() {
 	var cards []*Card
 	cards, firstAg = c.dealPublicCards(1, RoundTurn)
 	//已亮牌并且有保险开始保险计算
-	if showcard && c.options.insuranceOpen {
+	if showcard && c.options.insuranceOpen && !c.doubleBoard() {
 		//保险计算结果
 		c.insuranceEnd(cards[0], RoundFlop)
 	}
@@ -559,15 +832,19 @@ func (c *Holdem) startHand() {
 	}
 	//广播主边池内容
 	c.sendPotsInfo(users, RoundTurn)
+	//已亮牌可以选择多次发牌
+	if showcard && c.runMultiple(users) {
+		return
+	}
 	//已亮牌并且有保险开始保险逻辑
-	if showcard && c.options.insuranceOpen {
+	if showcard && c.options.insuranceOpen && !c.doubleBoard() {
 		//等待买保险
 		c.insuranceStart(users, RoundTurn)
 	}
 	//洗牌,并发送1张公共牌
 	cards, firstAg = c.dealPublicCards(1, RoundRiver)
 	//已亮牌并且有保险开始保险计算
-	if showcard && c.options.insuranceOpen {
+	if showcard && c.options.insuranceOpen && !c.doubleBoard() {
 		//保险计算结果
 		c.insuranceEnd(cards[0], RoundTurn)
 	}
@@ -583,12 +860,15 @@ func (c *Holdem) startHand() {
 	}
 	c.sendPotsInfo(users, RoundRiver)
 	//比牌计算结果
+	if c.doubleBoard() {
+		c.complexWin(users, c.publicCards, c.secondBoard)
+		return
+	}
 	c.complexWin(users)
 }
 
 //gameLoop 游戏逻辑
 func (c *Holdem) gameLoop() {
-	c.gameStatusCh = make(chan int8)
 	defer close(c.gameStatusCh)
 	v := <-c.gameStatusCh
 	if v == GameStatusCancel {
@@ -609,29 +889,75 @@ func (c *Holdem) gameLoop() {
 	for _, r := range c.roomers {
 		r.recv.RoomerGameStart(c.id)
 	}
+	if c.rebuy != nil {
+		c.rebuy.start = time.Now()
+	}
 	c.seatLock.Unlock()
 	for {
+		if c.blinds != nil {
+			//按盲注表升级(休息时在这里等待)
+			c.nextBlindLevel()
+		}
+		c.applyPendingChips()
+		if c.sng == nil {
+			c.autoTopUp()
+		}
+		c.sweepTransfers()
 		ok := c.buttonPosition()
 		if !ok {
+			if c.stopped() || c.sngOver() {
+				c.gameEnd()
+				return
+			}
 			c.log.Debug("players are not enough, wait")
 			time.Sleep(c.options.waitForNotEnoughPlayers)
 			continue
 		}
 		if c.nextSb > 0 {
 			c.sb = uint(c.nextSb)
+			c.bb = c.sb * 2
+			if c.nextBb > 0 {
+				c.bb = uint(c.nextBb)
+			}
 			c.nextSb = -1
+			c.nextBb = -1
 		}
 		if c.nextAnte >= 0 {
 			c.ante = uint(c.nextAnte)
 			c.nextAnte = -1
 		}
+		c.bombPot = c.nextBombPot
+		c.nextBombPot = nil
+		if c.blinds != nil {
+			c.applyBlindLevel()
+		}
 		c.log.Debug("hand start")
+		if c.sng != nil {
+			c.sngHandBegin()
+		}
 		c.startHand()
 		//清理座位用户
 		waitforbuy := false
 		bt := time.Now()
+		busted := make([]*Agent, 0)
 		c.seatLock.Lock()
+		//检查筹码守恒
+		c.checkLedger()
 		for i, r := range c.players {
+			if r.gameInfo.chip == 0 && c.sng != nil {
+				//等待重购的玩家
+				if c.sng.startChips[r] == 0 {
+					continue
+				}
+				//还可以重购的等待一段时间后淘汰,否则直接淘汰
+				if c.canRebuy(r) {
+					waitforbuy = true
+					c.delayStandUp(i, r, c.options.delayStandUpTimeout, StandUpEliminated)
+					continue
+				}
+				busted = append(busted, r)
+				continue
+			}
 			if c.options.autoStandUpMaxHand > 0 && r.auto && r.gameInfo.autoHandNum >= c.options.autoStandUpMaxHand {
 				c.log.Debug("user stand up auto", zap.Int8("seat", i), zap.String("user", r.ID()))
 				c.standUp(i, r, StandUpAutoExceedMaxTimes)
@@ -647,42 +973,67 @@ func (c *Holdem) gameLoop() {
 				c.standUp(i, r, r.gameInfo.needStandUpReason)
 			}
 		}
+		if c.sng != nil {
+			c.sngEliminate(busted)
+		}
 		info := c.information()
 		c.seatLock.Unlock()
+		c.doTransfers()
 		c.log.Debug("hand end")
-		next := c.nextGame(info)
+		next := !c.stopped() && !c.sngOver() && c.nextGame(info)
 		if next {
 			//清理座位用户
 			c.log.Debug("hand end")
 			if waitforbuy {
-				wait := time.Since(bt) - c.options.delayStandUpTimeout - 500*time.Millisecond
+				//等到补充筹码的时间结束(没有补充的已经站起/淘汰)
+				wait := c.options.delayStandUpTimeout + 500*time.Millisecond - time.Since(bt)
 				if wait > 0 {
 					time.Sleep(wait)
 				}
 			}
 			c.seatLock.Lock()
-			for _, r := range c.players {
+			for i, r := range c.players {
 				r.gameInfo.resetForNextHand()
+				//两手之间被要求站起的玩家
+				if r.gameInfo.needStandUpReason != StandUpNone {
+					c.log.Debug("user stand up", zap.Int8("seat", i), zap.String("user", r.ID()))
+					c.standUp(i, r, r.gameInfo.needStandUpReason)
+				}
 			}
 			c.seatLock.Unlock()
+			c.doTransfers()
 			continue
 		}
-		c.statusChange(GameStatusComplete)
-		//清理座位用户
-		c.seatLock.Lock()
-		for i, r := range c.players {
-			r.gameInfo.resetForNextHand()
-			c.log.Debug("user end stand up", zap.Int8("seat", i), zap.String("user", r.ID()))
-			c.standUp(i, r, StandUpGameEnd)
-		}
-		c.seatLock.Unlock()
-		c.options.recorder.GameEnd(c.base())
-		c.seatLock.Lock()
-		for _, r := range c.roomers {
-			r.recv.RoomerGameEnd(c.id)
-		}
-		c.seatLock.Unlock()
-		c.log.Debug("game end")
+		c.gameEnd()
 		return
 	}
 }
+
+//gameEnd 游戏结束
+func (c *Holdem) gameEnd() {
+	c.statusChange(GameStatusComplete)
+	//清理座位用户
+	c.seatLock.Lock()
+	payouts := c.payouts()
+	for i, r := range c.players {
+		r.gameInfo.resetForNextHand()
+		c.log.Debug("user end stand up", zap.Int8("seat", i), zap.String("user", r.ID()))
+		c.standUp(i, r, StandUpGameEnd)
+	}
+	c.seatLock.Unlock()
+	//结束前要求换桌的玩家
+	c.doTransfers()
+	if payouts != nil {
+		c.options.recorder.Payout(c.base(), payouts)
+	}
+	c.options.recorder.GameEnd(c.base())
+	c.seatLock.Lock()
+	for _, r := range c.roomers {
+		if payouts != nil {
+			r.recv.RoomerGetPayouts(c.id, payouts)
+		}
+		r.recv.RoomerGameEnd(c.id)
+	}
+	c.seatLock.Unlock()
+	c.log.Debug("game end")
+}
@@ -31,13 +31,33 @@ type Result struct {
 	HandValueType   HandValueType
 	Chip            uint
 	InsuranceResult map[Round]*InsuranceResult
+	//Runs 多次发牌时每次的结果
+	Runs []*RunResult
+	//Bounties 淘汰其他玩家获得的赏金
+	Bounties []*BountyAward
+	//Rake 从这个座位赢得的池中扣除的抽水(Num已经扣除)
+	Rake uint
+	//Jackpot 坏牌奖池的奖金(已经加到Chip)
+	Jackpot uint
+}
+
+//RunResult 多次发牌中某一次的结果
+type RunResult struct {
+	//Run 第几次(从1开始)
+	Run int8
+	//PublicCards 这次的公共牌
+	PublicCards []*Card
+	//Num 这次赢得的数量
+	Num           uint
+	Cards         []*CardResult
+	HandValueType HandValueType
 }
 
 //showDown 亮牌并计算获胜牌型，返回获胜的玩家和剩余的
-func (c *Holdem) showDown(agents []*Agent) ([]*Agent, []*Agent) {
+func (c *Holdem) showDown(agents []*Agent, board []*Card) ([]*Agent, []*Agent) {
 	th := make(map[int8]*HandValue)
 	for _, r := range agents {
-		r.gameInfo.calcHandValue(c.publicCards)
+		r.gameInfo.calcHandValue(board, c.maxHandValue)
 		th[r.gameInfo.seatNumber] = r.gameInfo.handValue
 	}
 	th = GetMaxHandValueFromTaggedHandValues(th)
@@ -54,9 +74,28 @@ func (c *Holdem) showDown(agents []*Agent) ([]*Agent, []*Agent) {
 	return ret, left
 }
 
+//maxHandValue 根据玩法计算最大牌型(德州任意组合,奥马哈2张手牌+3张公共牌)
+func (c *Holdem) maxHandValue(hole []*Card, board []*Card) (*HandValue, error) {
+	if c.options.omaha {
+		return GetMaxOmahaHandValueFromCard(hole, board, c.options.ranking)
+	}
+	cards := make([]*Card, 0, len(board)+len(hole))
+	cards = append(cards, board...)
+	cards = append(cards, hole...)
+	return GetMaxHandValueFromCard(cards, c.options.ranking)
+}
+
+//fastHandValue 按玩法快速计算最大牌型值(只比较大小时使用)
+func (c *Holdem) fastHandValue(hole []*Card, board []*Card) (int64, HandValueType) {
+	if c.options.omaha {
+		return omahaBitsValue(hole, board, c.options.ranking)
+	}
+	return bitsValue(hole, board, c.options.ranking)
+}
+
 //calcWin 根据彩池和牌型分配奖励
-func (c *Holdem) calcWin(urs []*Agent, pots []*Pot) (map[int8]*Result, []*Agent, []*Pot) {
-	winners, leftUsers := c.showDown(urs)
+func (c *Holdem) calcWin(urs []*Agent, pots []*Pot, board []*Card) (map[int8]*Result, []*Agent, []*Pot) {
+	winners, leftUsers := c.showDown(urs, board)
 	leftPots := make([]*Pot, 0)
 	results := make(map[int8]*Result)
 	for _, pot := range pots {
@@ -78,14 +117,16 @@ func (c *Holdem) calcWin(urs []*Agent, pots []*Pot) (map[int8]*Result, []*Agent,
 				v.Num = award
 				result[i] = v
 			}
+			//零头给庄位后第一个赢家
 			if left > 0 {
-				u := c.button
+				u := c.button.nextAgent
 				for {
 					if v, ok := result[u.gameInfo.seatNumber]; ok {
 						v.Num += left
 						result[u.gameInfo.seatNumber] = v
 						break
 					}
+					u = u.nextAgent
 				}
 			}
 		} else {
@@ -102,7 +143,7 @@ func (c *Holdem) calcWin(urs []*Agent, pots []*Pot) (map[int8]*Result, []*Agent,
 	}
 	for len(leftPots) > 0 {
 		var r2 map[int8]*Result
-		r2, leftUsers, leftPots = c.calcWin(leftUsers, leftPots)
+		r2, leftUsers, leftPots = c.calcWin(leftUsers, leftPots, board)
 		for _, v := range r2 {
 			results[v.SeatNumber] = v
 		}
@@ -112,68 +153,66 @@ func (c *Holdem) calcWin(urs []*Agent, pots []*Pot) (map[int8]*Result, []*Agent,
 
 //calcPot 计算彩池(彩池边池，下注大小从小到大)
 func (c *Holdem) calcPot(urs []*Agent) []*Pot {
-	var mainPot uint
+	//按全下额度分层,最后一层为最大下注
+	levels := make([]uint, 0)
+	exists := make(map[uint]bool)
+	var max uint
 	u := c.button
-	as := make([]*Agent, 0)
-	users := make(map[int8]*Agent)
-	for _, r := range urs {
-		as = append(as, r)
-		users[r.gameInfo.seatNumber] = r
-	}
-	ps := betSort(as)
-	sort.Sort(ps)
 	for {
-		//不是最终玩家
-		if _, ok := users[u.gameInfo.seatNumber]; !ok {
-			mainPot += u.gameInfo.handBet
-		} else {
-			if u.gameInfo.status != ActionDefAllIn {
-				mainPot += u.gameInfo.handBet
-			}
+		if u.gameInfo.handBet > max {
+			max = u.gameInfo.handBet
 		}
 		u = u.nextAgent
 		if u == c.button {
 			break
 		}
 	}
-	seats := make(map[int8]bool)
-	for _, r := range ps {
-		seats[r.gameInfo.seatNumber] = true
+	for _, r := range urs {
+		if r.gameInfo.status == ActionDefAllIn && !exists[r.gameInfo.handBet] {
+			exists[r.gameInfo.handBet] = true
+			levels = append(levels, r.gameInfo.handBet)
+		}
+	}
+	if !exists[max] {
+		levels = append(levels, max)
 	}
-	l := uint(len(ps))
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i] < levels[j]
+	})
 	pots := make([]*Pot, 0)
-	var lastAllIn uint
-	for i, r := range ps {
-		if r.gameInfo.status == ActionDefAllIn {
-			if r.gameInfo.handBet == lastAllIn {
-				l--
-				delete(seats, r.gameInfo.seatNumber)
-				continue
-			}
-			pot := &Pot{
-				Num: l * (r.gameInfo.handBet - lastAllIn),
+	var last uint
+	for _, level := range levels {
+		pot := &Pot{
+			SeatNumber: make(map[int8]bool),
+		}
+		//每个人(包括盖牌的)在这一层的投入
+		u := c.button
+		for {
+			if bet := u.gameInfo.handBet; bet > last {
+				if bet > level {
+					bet = level
+				}
+				pot.Num += bet - last
 			}
-			if i == 0 {
-				pot.Num += mainPot
+			u = u.nextAgent
+			if u == c.button {
+				break
 			}
-			ss := make(map[int8]bool)
-			for k, v := range seats {
-				ss[k] = v
+		}
+		for _, r := range urs {
+			if r.gameInfo.handBet >= level {
+				pot.SeatNumber[r.gameInfo.seatNumber] = true
 			}
-			pot.SeatNumber = ss
-			pots = append(pots, pot)
-			l--
-			lastAllIn = r.gameInfo.handBet
-			delete(seats, r.gameInfo.seatNumber)
+		}
+		last = level
+		//没有人可以分配的部分(盖牌的人多下的),并入上一个池
+		if len(pot.SeatNumber) == 0 && len(pots) > 0 {
+			pots[len(pots)-1].Num += pot.Num
 			continue
 		}
-		//mainPot += r.gameInfo.handBet
-	}
-	if len(pots) == 0 {
-		pots = append(pots, &Pot{
-			SeatNumber: seats,
-			Num:        mainPot,
-		})
+		if pot.Num > 0 || len(pots) == 0 {
+			pots = append(pots, pot)
+		}
 	}
 	return pots
 }
@@ -0,0 +1,1126 @@
+package holdem
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+//testAction 记录下来的动作
+type testAction struct {
+	round  Round
+	seat   int8
+	action ActionDef
+	num    uint
+}
+
+//testRecorder 按手记录开始状态/动作/结果
+type testRecorder struct {
+	NopRecorder
+	mu      sync.Mutex
+	states  []*HoldemState
+	chips   []map[int8]uint
+	actions [][]*testAction
+	results [][]*Result
+	payouts []*Payout
+	end     chan bool
+}
+
+func (c *testRecorder) HandBegin(s *HoldemState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states = append(c.states, s)
+	//ShowUser会被后面的动作修改,这里先记下筹码
+	chips := make(map[int8]uint)
+	for _, u := range s.Seated {
+		chips[u.SeatNumber] = u.Chip
+	}
+	c.chips = append(c.chips, chips)
+	c.actions = append(c.actions, nil)
+}
+
+func (c *testRecorder) Ante(base *HoldemBase, seat int8, id string, chip uint, num uint) {
+	c.Action(base, RoundPreFlop, seat, id, chip, ActionDefAnte, num)
+}
+
+func (c *testRecorder) Action(base *HoldemBase, round Round, seat int8, id string, chip uint, action ActionDef, num uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := len(c.actions) - 1
+	c.actions[i] = append(c.actions[i], &testAction{round, seat, action, num})
+}
+
+func (c *testRecorder) HandEnd(s *HoldemState, r []*Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, r)
+}
+
+func (c *testRecorder) Payout(base *HoldemBase, payouts []*Payout) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payouts = payouts
+}
+
+func (c *testRecorder) GameEnd(*HoldemBase) {
+	close(c.end)
+}
+
+//filter 某一手中某种动作(按发生顺序)
+func (c *testRecorder) filter(hand int, action ActionDef) []*testAction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*testAction, 0)
+	for _, a := range c.actions[hand] {
+		if a.action == action {
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+//chip 某一手开始时某个座位的筹码
+func (c *testRecorder) chip(hand int, seat int8) uint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chips[hand][seat]
+}
+
+//testPlayer 记录收到的错误码和盲注级别
+type testPlayer struct {
+	NopReciever
+	mu     sync.Mutex
+	errs   []int
+	levels []int
+	hands  []uint
+	durs   []time.Duration
+	boards []*Card
+	runs   int
+	prizes []*Payout
+	ended  bool
+}
+
+func (c *testPlayer) ErrorOccur(hid string, code int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, code)
+}
+
+func (c *testPlayer) RoomerGetBlindLevel(hid string, level int, current *BlindLevel, next *BlindLevel, hands uint, dur time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.levels = append(c.levels, level)
+	c.hands = append(c.hands, hands)
+	c.durs = append(c.durs, dur)
+}
+
+func (c *testPlayer) RoomerGetRunCards(hid string, run int8, cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs++
+}
+
+func (c *testPlayer) RoomerGetSecondBoard(hid string, cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.boards = append(c.boards, cards...)
+}
+
+func (c *testPlayer) RoomerGetPayouts(hid string, payouts []*Payout) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prizes = payouts
+}
+
+func (c *testPlayer) RoomerGameEnd(hid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ended = true
+}
+
+//payouts 等到游戏结束的通知,返回收到的单桌赛奖励
+func (c *testPlayer) payouts() []*Payout {
+	for i := 0; i < 1000; i++ {
+		c.mu.Lock()
+		ended, prizes := c.ended, c.prizes
+		c.mu.Unlock()
+		if ended {
+			return prizes
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+//lastErr 错误数量和最后一个错误码
+func (c *testPlayer) lastErr() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) == 0 {
+		return 0, 0
+	}
+	return len(c.errs), c.errs[len(c.errs)-1]
+}
+
+//testGame 真实的牌局,按行动顺序执行下注脚本
+type testGame struct {
+	t       *testing.T
+	h       *Holdem
+	agents  []*Agent
+	players []*testPlayer
+	rec     *testRecorder
+}
+
+//newTestGame 小盲10的牌局,玩家p1,p2...依次坐在1,2...号座位(筹码为0的只加入不坐下)
+//打完hands手结束,between在每手结束后(下一手开始前)调用
+func newTestGame(t *testing.T, hands uint, chips []uint, between func(*HoldemState), ops ...HoldemOption) *testGame {
+	rec := &testRecorder{end: make(chan bool)}
+	g := &testGame{t: t, rec: rec}
+	next := func(s *HoldemState) bool {
+		//自动开始时也会调用(第0手)
+		if between != nil && s.HandNum > 0 {
+			between(s)
+		}
+		return s.HandNum < hands
+	}
+	ops = append([]HoldemOption{OptionCustomRecorder(rec)}, ops...)
+	g.h = NewHoldem("t", int8(len(chips)), 10, time.Second, next, zap.NewNop(), ops...)
+	for i, chip := range chips {
+		p := &testPlayer{}
+		a := NewAgent(p, fmt.Sprintf("p%d", i+1), zap.NewNop())
+		a.Join(g.h)
+		if chip > 0 {
+			a.BringIn(chip)
+			a.Seated(int8(i + 1))
+		}
+		g.agents = append(g.agents, a)
+		g.players = append(g.players, p)
+	}
+	return g
+}
+
+//start 开始游戏
+func (c *testGame) start() {
+	c.h.Start()
+}
+
+//turn 等待轮到某个玩家行动
+func (c *testGame) turn() int {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		for i, a := range c.agents {
+			if a.canBet() {
+				return i
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.t.Fatal("no player to act")
+	return -1
+}
+
+//play 依次让轮到的玩家下注,返回每次下注的错误码(0为成功),没有数量的全下/跟注为全部筹码/需要跟注的数量
+func (c *testGame) play(bets ...*Bet) []int {
+	codes := make([]int, 0, len(bets))
+	for _, bet := range bets {
+		i := c.turn()
+		n, _ := c.players[i].lastErr()
+		if bet.Action == ActionDefAllIn && bet.Num == 0 {
+			bet = &Bet{Action: ActionDefAllIn, Num: c.agents[i].gameInfo.chip}
+		}
+		if bet.Action == ActionDefCall && bet.Num == 0 {
+			bet = &Bet{Action: ActionDefCall, Num: c.h.roundBet - c.agents[i].gameInfo.roundBet}
+		}
+		c.agents[i].Bet(bet)
+		code := 0
+		for c.agents[i].canBet() {
+			if cnt, last := c.players[i].lastErr(); cnt > n {
+				code = last
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+//auto 轮到谁都按bet行动,直到游戏结束
+func (c *testGame) auto(bet func(a *Agent) *Bet) {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-c.rec.end:
+			return
+		default:
+		}
+		for _, a := range c.agents {
+			if a.canBet() {
+				a.Bet(bet(a))
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.t.Fatal("game is not over")
+}
+
+//testAllIn 全下
+func testAllIn(a *Agent) *Bet {
+	return &Bet{Action: ActionDefAllIn, Num: a.gameInfo.chip}
+}
+
+//wait 等待游戏结束
+func (c *testGame) wait() {
+	select {
+	case <-c.rec.end:
+	case <-time.After(30 * time.Second):
+		c.t.Fatal("game is not over")
+	}
+}
+
+func TestBettingStructure(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name    string
+		betting BettingStructure
+		bets    []*Bet
+		codes   []int
+		raises  []uint
+	}{
+		//枪口最少加注到大盲的2倍
+		{"no limit min raise", &NoLimit{}, []*Bet{
+			{Action: ActionDefRaise, Num: 30},
+			{Action: ActionDefRaise, Num: 40},
+			{Action: ActionDefFold},
+			{Action: ActionDefFold},
+		}, []int{ErrCodeInvalidBetNum, 0, 0, 0}, []uint{40}},
+		//跟注20后底池50,最多投入70;小盲跟注60后底池160,最多投入220
+		{"pot limit", &PotLimit{}, []*Bet{
+			{Action: ActionDefRaise, Num: 90},
+			{Action: ActionDefRaise, Num: 70},
+			{Action: ActionDefRaise, Num: 221},
+			{Action: ActionDefRaise, Num: 220},
+			{Action: ActionDefFold},
+			{Action: ActionDefFold},
+		}, []int{ErrCodeInvalidBetNum, 0, ErrCodeInvalidBetNum, 0, 0, 0}, []uint{70, 220}},
+		//每次加注一个大盲,大盲算第一次下注,第4次加注后不能再加
+		{"fixed limit cap", &FixedLimit{}, []*Bet{
+			{Action: ActionDefRaise, Num: 50},
+			{Action: ActionDefRaise, Num: 40},
+			{Action: ActionDefRaise, Num: 50},
+			{Action: ActionDefRaise, Num: 60},
+			{Action: ActionDefRaise, Num: 60},
+			{Action: ActionDefFold},
+			{Action: ActionDefFold},
+		}, []int{ErrCodeInvalidBetNum, 0, 0, 0, ErrCodeInvalidBetAction, 0, 0}, []uint{40, 50, 60}},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, []uint{1000, 1000, 1000}, nil, OptionBettingStructure(cs.betting))
+		g.start()
+		assert.Equal(cs.codes, g.play(cs.bets...), cs.name)
+		g.wait()
+		raises := make([]uint, 0)
+		for _, a := range g.rec.filter(0, ActionDefRaise) {
+			raises = append(raises, a.num)
+		}
+		assert.Equal(cs.raises, raises, cs.name)
+		//没有人跟注,最后加注的人赢下底池
+		var total uint
+		for _, r := range g.rec.results[0] {
+			total += r.Chip
+		}
+		assert.Equal(uint(3000), total, cs.name)
+	}
+}
+
+//testSeatFrom 从某个座位开始的第n个座位(所有座位都有人)
+func testSeatFrom(seat int8, n int, count int) int8 {
+	return int8((int(seat)-1+n)%count + 1)
+}
+
+func TestStraddle(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name      string
+		max       int8
+		button    bool
+		declared  bool
+		straddles []int
+		first     int
+	}{
+		//位置按庄位开始数:0庄位,1小盲,2大盲,3枪口...
+		{"utg", 1, false, true, []int{3}, 4},
+		{"chain", 2, false, true, []int{3, 4}, 0},
+		//连续抓头可以到庄位,小盲开始行动
+		{"chain to button", 3, false, true, []int{3, 4, 0}, 1},
+		//庄位抓头只有一次,小盲开始行动
+		{"button", 2, true, true, []int{0}, 1},
+		{"not declared", 2, true, false, nil, 3},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, []uint{1000, 1000, 1000, 1000, 1000}, nil, OptionStraddle(cs.max, cs.button))
+		if cs.declared {
+			for _, a := range g.agents {
+				a.Straddle()
+			}
+		}
+		g.start()
+		//都盖牌给最后一个抓头的(没有抓头时是大盲)
+		assert.Equal([]int{0, 0, 0, 0}, g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold}), cs.name)
+		g.wait()
+		button := testSeatFrom(g.rec.filter(0, ActionDefSB)[0].seat, -1+5, 5)
+		straddles := g.rec.filter(0, ActionDefStraddle)
+		if assert.Equal(len(cs.straddles), len(straddles), cs.name) {
+			amount := uint(40)
+			for i, pos := range cs.straddles {
+				assert.Equal(testSeatFrom(button, pos, 5), straddles[i].seat, cs.name)
+				assert.Equal(amount, straddles[i].num, cs.name)
+				amount *= 2
+			}
+		}
+		assert.Equal(testSeatFrom(button, cs.first, 5), g.rec.filter(0, ActionDefFold)[0].seat, cs.name)
+	}
+}
+
+func TestRunMultiple(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name  string
+		votes [][]int8
+		codes []int
+		runs  int
+	}{
+		{"twice", [][]int8{{2}, {2}}, []int{0, 0}, 2},
+		//取所有人选择的最小值
+		{"min", [][]int8{{3}, {2}}, []int{0, 0}, 2},
+		{"once", [][]int8{{3}, {1}}, []int{0, 0}, 0},
+		//超过最多次数的选择无效,可以重新选择
+		{"invalid", [][]int8{{4, 3}, {3}}, []int{ErrCodeInvalidRunTimes, 0}, 3},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, []uint{1000, 1000}, nil, OptionRunMultiple(3, 5*time.Second))
+		g.start()
+		assert.Equal([]int{0, 0}, g.play(&Bet{Action: ActionDefAllIn}, &Bet{Action: ActionDefAllIn}), cs.name)
+		for i, a := range g.agents {
+			for j := 0; j < 5000 && !a.canRunMultiple(); j++ {
+				time.Sleep(time.Millisecond)
+			}
+			for _, v := range cs.votes[i] {
+				a.RunMultiple(v)
+			}
+		}
+		g.wait()
+		for i, p := range g.players {
+			_, code := p.lastErr()
+			assert.Equal(cs.codes[i], code, cs.name)
+		}
+		var total uint
+		for _, r := range g.rec.results[0] {
+			total += r.Chip
+			if !assert.Equal(cs.runs, len(r.Runs), cs.name) || cs.runs == 0 {
+				continue
+			}
+			var num uint
+			for i, run := range r.Runs {
+				assert.Equal(int8(i+1), run.Run, cs.name)
+				assert.Equal(5, len(run.PublicCards), cs.name)
+				num += run.Num
+			}
+			assert.Equal(r.Num, num, cs.name)
+		}
+		assert.Equal(uint(2000), total, cs.name)
+		//每次发的公共牌都不一样
+		if cs.runs > 0 {
+			seen := make(map[string]bool)
+			for _, run := range g.rec.results[0][0].Runs {
+				for _, cd := range run.PublicCards {
+					assert.False(seen[cd.String()], cs.name)
+					seen[cd.String()] = true
+				}
+			}
+		}
+	}
+}
+
+func TestBombPot(t *testing.T) {
+	assert := assert.New(t)
+	checks := make([]*Bet, 9)
+	for i := range checks {
+		checks[i] = &Bet{Action: ActionDefCheck}
+	}
+	for _, double := range []bool{false, true} {
+		var g *testGame
+		g = newTestGame(t, 2, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+			if s.HandNum == 1 {
+				g.h.NextBombPot(50, double)
+			}
+		})
+		g.start()
+		//第一手普通牌局
+		g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+		//炸弹底池没有盲注和翻牌前下注,之后每条街都过牌
+		assert.Equal(make([]int, 9), g.play(checks...))
+		g.wait()
+		assert.Equal(0, len(g.rec.filter(1, ActionDefSB)))
+		assert.Equal(0, len(g.rec.filter(1, ActionDefBB)))
+		antes := g.rec.filter(1, ActionDefAnte)
+		if assert.Equal(3, len(antes)) {
+			for _, a := range antes {
+				assert.Equal(uint(50), a.num)
+			}
+		}
+		for _, a := range g.rec.actions[1] {
+			if a.action != ActionDefAnte {
+				assert.NotEqual(RoundPreFlop, a.round)
+			}
+		}
+		var total, win uint
+		for _, r := range g.rec.results[1] {
+			total += r.Chip
+			win += r.Num
+			if !double {
+				assert.Equal(0, len(r.Runs))
+				continue
+			}
+			//双公共牌每组平分底池
+			if assert.Equal(2, len(r.Runs)) {
+				assert.Equal(5, len(r.Runs[0].PublicCards))
+				assert.Equal(5, len(r.Runs[1].PublicCards))
+				assert.NotEqual(r.Runs[0].PublicCards[0].String(), r.Runs[1].PublicCards[0].String())
+				assert.Equal(r.Num, r.Runs[0].Num+r.Runs[1].Num)
+				//第二组公共牌单独通知,不是多次发牌
+				assert.Equal(r.Runs[1].PublicCards, g.players[0].boards)
+				assert.Equal(0, g.players[0].runs)
+			}
+		}
+		assert.Equal(uint(3000), total)
+		assert.Equal(uint(150), win)
+	}
+}
+
+func TestAnteAllIn(t *testing.T) {
+	assert := assert.New(t)
+	var g *testGame
+	var short uint
+	g = newTestGame(t, 2, []uint{1000, 1000, 60}, func(s *HoldemState) {
+		//第二手前注大于3号的筹码
+		if s.HandNum == 1 {
+			short = g.agents[2].gameInfo.chip
+			g.h.ChangeBetConfig(10, 200)
+		}
+	})
+	g.start()
+	g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+	assert.Equal([]int{0, 0}, g.play(&Bet{Action: ActionDefAllIn}, &Bet{Action: ActionDefAllIn}))
+	g.wait()
+	antes := g.rec.filter(1, ActionDefAnte)
+	if assert.Equal(3, len(antes)) {
+		for _, a := range antes {
+			if a.seat == 3 {
+				assert.Equal(short, a.num)
+				continue
+			}
+			assert.Equal(uint(200), a.num)
+		}
+	}
+	var total uint
+	for _, r := range g.rec.results[1] {
+		total += r.Chip
+		//前注全下的只能赢主池
+		if r.SeatNumber == 3 {
+			assert.True(r.Num <= short*3)
+		}
+	}
+	assert.Equal(uint(2060), total)
+}
+
+//testRabbitRecorder 额外记录看过的剩余公共牌
+type testRabbitRecorder struct {
+	*testRecorder
+	rabbits [][]*Card
+}
+
+func (c *testRabbitRecorder) RabbitHunt(base *HoldemBase, seat int8, id string, chip uint, cost uint, cards []*Card) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rabbits = append(c.rabbits, cards)
+}
+
+func TestRabbitHunt(t *testing.T) {
+	assert := assert.New(t)
+	var g *testGame
+	var expects [][]*Card
+	codes := make([][]int, 0)
+	hunt := func(i int) int {
+		n, _ := g.players[i].lastErr()
+		g.agents[i].RabbitHunt()
+		if cnt, code := g.players[i].lastErr(); cnt > n {
+			return code
+		}
+		return 0
+	}
+	g = newTestGame(t, 2, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+		//翻牌前结束,剩余公共牌是烧牌后的3张,烧牌后的1张,烧牌后的1张
+		idx, cards := g.h.poker.currentIndex, g.h.poker.cards
+		expects = append(expects, []*Card{cards[idx+1], cards[idx+2], cards[idx+3], cards[idx+5], cards[idx+7]})
+		chip := g.agents[0].gameInfo.chip
+		if s.HandNum == 1 {
+			//每手只能看一次
+			codes = append(codes, []int{hunt(0), hunt(1)})
+			assert.Equal(chip-5, g.agents[0].gameInfo.chip)
+			return
+		}
+		//每个人最多看一次
+		codes = append(codes, []int{hunt(0), hunt(1)})
+		assert.Equal(chip, g.agents[0].gameInfo.chip)
+	}, OptionRabbitHunt(5, 1))
+	rec := &testRabbitRecorder{testRecorder: g.rec}
+	g.h.options.recorder = rec
+	//还没有提前结束的一手
+	assert.Equal(ErrCodeCannotRabbitHunt, hunt(2))
+	g.start()
+	g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+	g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+	g.wait()
+	assert.Equal([][]int{{0, ErrCodeCannotRabbitHunt}, {ErrCodeRabbitHuntOverTimes, 0}}, codes)
+	if assert.Equal(2, len(rec.rabbits)) {
+		assert.Equal(expects, rec.rabbits)
+	}
+}
+
+func TestSitAndGo(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name      string
+		prizePool uint
+		payouts   []uint
+		prizes    []uint
+	}{
+		//零头给第一名
+		{"odd chip", 1001, []uint{60, 40}, []uint{601, 400, 0}},
+		{"all paid", 1000, []uint{50, 30, 20}, []uint{500, 300, 200}},
+		//百分比加起来不是100时不补零头
+		{"partial", 1001, []uint{50, 30}, []uint{500, 300, 0}},
+	}
+	for _, cs := range cases {
+		//坐满自动开始
+		g := newTestGame(t, 100, []uint{1000, 1000, 1000}, nil, OptionSitAndGo(cs.prizePool, cs.payouts))
+		g.auto(testAllIn)
+		payouts := g.rec.payouts
+		if !assert.Equal(3, len(payouts), cs.name) {
+			continue
+		}
+		//每手结束时还有筹码的玩家,淘汰的按手数先后排名
+		busted := make(map[string]int)
+		for hand, rs := range g.rec.results {
+			for _, r := range rs {
+				id := fmt.Sprintf("p%d", r.SeatNumber)
+				if _, ok := busted[id]; !ok && r.Chip == 0 {
+					busted[id] = hand
+				}
+			}
+		}
+		for i, p := range payouts {
+			assert.Equal(i+1, p.Position, cs.name)
+			assert.Equal(cs.prizes[i], p.Prize, cs.name)
+		}
+		_, ok := busted[payouts[0].UserID]
+		assert.False(ok, cs.name)
+		assert.True(busted[payouts[1].UserID] >= busted[payouts[2].UserID], cs.name)
+		var total uint
+		for _, r := range g.rec.results[len(g.rec.results)-1] {
+			total += r.Chip
+		}
+		assert.Equal(uint(3000), total, cs.name)
+		//每个玩家都收到名次和奖励
+		for _, p := range g.players {
+			assert.Equal(payouts, p.payouts(), cs.name)
+		}
+	}
+}
+
+func TestBlindsAllIn(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name  string
+		chips []uint
+		ante  uint
+	}{
+		//盲注后没有可以行动的玩家
+		{"blinds", []uint{5, 8}, 0},
+		{"antes", []uint{5, 5, 5}, 5},
+		{"antes and blinds", []uint{10, 15, 30}, 10},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, cs.chips, nil, OptionAllowShortStack(), OptionAnte(cs.ante))
+		g.start()
+		g.auto(testAllIn)
+		var total, sum uint
+		for _, chip := range cs.chips {
+			sum += chip
+		}
+		for _, r := range g.rec.results[0] {
+			total += r.Chip
+		}
+		assert.Equal(sum, total, cs.name)
+		assert.Equal(len(cs.chips), len(g.rec.results[0]), cs.name)
+	}
+}
+
+func TestBlindSchedule(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name     string
+		schedule BlindSchedule
+		sleep    time.Duration
+		blinds   [][2]uint
+		antes    []int
+		levels   []int
+		hands    []uint
+	}{
+		{"hands", BlindSchedule{
+			{SmallBlind: 10, Hands: 2},
+			{SmallBlind: 20, BigBlind: 50, Ante: 5, Hands: 1},
+			{SmallBlind: 40},
+		}, 0, [][2]uint{{10, 20}, {10, 20}, {20, 50}, {40, 80}}, []int{0, 0, 3, 0}, []int{0, 0, 1, 2}, []uint{1, 0, 0, 0}},
+		//每手结束等待到时间后升级
+		{"duration", BlindSchedule{
+			{SmallBlind: 10, Duration: 300 * time.Millisecond},
+			{SmallBlind: 20, Duration: time.Hour},
+			{SmallBlind: 40},
+		}, 300 * time.Millisecond, [][2]uint{{10, 20}, {20, 40}, {20, 40}}, []int{0, 0, 0}, []int{0, 1, 1}, []uint{0, 0, 0}},
+		//休息时沿用上一级广播倒计时,休息结束直接升级
+		{"break", BlindSchedule{
+			{SmallBlind: 10, Hands: 1},
+			{Break: true, Duration: 300 * time.Millisecond},
+			{SmallBlind: 20},
+		}, 0, [][2]uint{{10, 20}, {20, 40}}, []int{0, 0}, []int{0, 1, 2}, []uint{0, 0, 0}},
+	}
+	for _, cs := range cases {
+		hands := uint(len(cs.blinds))
+		g := newTestGame(t, hands, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+			if s.HandNum < hands {
+				time.Sleep(cs.sleep)
+			}
+		}, OptionBlindSchedule(cs.schedule))
+		g.start()
+		for range cs.blinds {
+			g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+		}
+		g.wait()
+		for i, blinds := range cs.blinds {
+			sb, bb := g.rec.filter(i, ActionDefSB), g.rec.filter(i, ActionDefBB)
+			if assert.Equal(1, len(sb), cs.name) && assert.Equal(1, len(bb), cs.name) {
+				assert.Equal(blinds, [2]uint{sb[0].num, bb[0].num}, "%s hand %d", cs.name, i+1)
+			}
+			assert.Equal(cs.antes[i], len(g.rec.filter(i, ActionDefAnte)), "%s hand %d", cs.name, i+1)
+		}
+		p := g.players[0]
+		assert.Equal(cs.levels, p.levels, cs.name)
+		assert.Equal(cs.hands, p.hands, cs.name)
+		for i, level := range p.levels {
+			if cs.schedule[level].Break {
+				assert.True(p.durs[i] > 0 && p.durs[i] <= cs.schedule[level].Duration, cs.name)
+			}
+		}
+	}
+}
+
+func TestRebuy(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name   string
+		policy *RebuyPolicy
+		codes  []int
+		chip   uint
+	}{
+		{"rebuy once", &RebuyPolicy{MaxRebuys: 1, Threshold: 5000, RebuyChip: 500, Price: 100}, []int{0, ErrCodeRebuyOverTimes}, 500},
+		{"rebuy twice", &RebuyPolicy{MaxRebuys: 2, Threshold: 5000, RebuyChip: 500, Price: 100}, []int{0, 0}, 1000},
+		//重购后的筹码也算在筹码里
+		{"threshold", &RebuyPolicy{MaxRebuys: 2, Threshold: 1100, RebuyChip: 500, Price: 100}, []int{0, ErrCodeRebuyTooManyChips}, 500},
+		{"too many chips", &RebuyPolicy{MaxRebuys: 1, Threshold: 100, RebuyChip: 500, Price: 100}, []int{ErrCodeRebuyTooManyChips}, 0},
+		{"not allowed", &RebuyPolicy{AddOnChip: 500, Price: 100}, []int{ErrCodeRebuyNotAllowed}, 0},
+		{"closed", &RebuyPolicy{Period: time.Millisecond, MaxRebuys: 1, Threshold: 5000, RebuyChip: 500, Price: 100}, []int{ErrCodeRebuyClosed}, 0},
+		//不是休息时间不能加码
+		{"add on", &RebuyPolicy{AddOnChip: 500, Price: 100}, nil, 0},
+	}
+	for _, cs := range cases {
+		var g *testGame
+		var chip uint
+		codes := make([]int, 0)
+		g = newTestGame(t, 2, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+			if s.HandNum != 1 {
+				return
+			}
+			chip = g.agents[0].gameInfo.chip
+			for range cs.codes {
+				n, _ := g.players[0].lastErr()
+				g.agents[0].Rebuy()
+				code := 0
+				if cnt, last := g.players[0].lastErr(); cnt > n {
+					code = last
+				}
+				codes = append(codes, code)
+			}
+			if cs.codes == nil {
+				g.agents[0].AddOn()
+				_, code := g.players[0].lastErr()
+				codes = append(codes, code)
+			}
+		}, OptionSitAndGo(3000, []uint{100}), OptionRebuy(cs.policy))
+		g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+		g.play(&Bet{Action: ActionDefFold}, &Bet{Action: ActionDefFold})
+		g.wait()
+		if cs.codes == nil {
+			assert.Equal([]int{ErrCodeAddOnNotAllowed}, codes, cs.name)
+		} else {
+			assert.Equal(cs.codes, codes, cs.name)
+		}
+		//下一手开始前加上筹码,每次重购加入奖池
+		assert.Equal(chip+cs.chip, g.rec.chip(1, 1), cs.name)
+		assert.Equal(3000+cs.chip/500*100, g.h.sng.prizePool, cs.name)
+	}
+}
+
+func TestRebuyAfterBust(t *testing.T) {
+	assert := assert.New(t)
+	var g *testGame
+	rebought := -1
+	hand := 0
+	g = newTestGame(t, 100, []uint{1000, 1000}, func(s *HoldemState) {
+		//第一次输光的玩家马上重购
+		for i, a := range g.agents {
+			if rebought < 0 && a.gameInfo != nil && a.gameInfo.chip == 0 {
+				a.Rebuy()
+				rebought, hand = i, int(s.HandNum)
+			}
+		}
+	}, OptionSitAndGo(2000, []uint{70, 30}), OptionWaitForRebuy(time.Second), OptionRebuy(&RebuyPolicy{MaxRebuys: 1, RebuyChip: 500, Price: 100}))
+	g.auto(testAllIn)
+	if !assert.True(rebought >= 0) {
+		return
+	}
+	//重购的玩家下一手带着重购的筹码继续
+	assert.Equal(uint(500), g.rec.chip(hand, int8(rebought+1)))
+	payouts := g.rec.payouts
+	if assert.Equal(2, len(payouts)) {
+		assert.Equal([]uint{1470, 630}, []uint{payouts[0].Prize, payouts[1].Prize})
+	}
+	var total uint
+	for _, r := range g.rec.results[len(g.rec.results)-1] {
+		total += r.Chip
+	}
+	assert.Equal(uint(2500), total)
+}
+
+func TestBounty(t *testing.T) {
+	assert := assert.New(t)
+	for _, progressive := range []bool{false, true} {
+		g := newTestGame(t, 100, []uint{1000, 1000, 1000}, nil, OptionSitAndGo(3000, []uint{100}), OptionBounty(100, progressive))
+		g.auto(testAllIn)
+		bounties := map[string]uint{"p1": 100, "p2": 100, "p3": 100}
+		eliminated := 0
+		for hand, rs := range g.rec.results {
+			busted := make(map[string]bool)
+			for _, r := range rs {
+				if r.Chip == 0 {
+					busted[fmt.Sprintf("p%d", r.SeatNumber)] = true
+				}
+			}
+			//每个输光的玩家身上的赏金都分给淘汰他的赢家
+			given := make(map[string]uint)
+			for _, r := range rs {
+				for _, a := range r.Bounties {
+					assert.Equal(r.SeatNumber, a.SeatNumber)
+					assert.True(r.Num > 0, "%v hand %d", progressive, hand)
+					assert.True(busted[a.Eliminated], "%v hand %d", progressive, hand)
+					if progressive {
+						assert.Equal(a.Added, (a.Cash+a.Added)/2)
+					} else {
+						assert.Equal(uint(0), a.Added)
+					}
+					given[a.Eliminated] += a.Cash + a.Added
+					bounties[a.UserID] += a.Added
+				}
+			}
+			for id := range busted {
+				assert.Equal(bounties[id], given[id], "%v hand %d", progressive, hand)
+				eliminated++
+			}
+		}
+		assert.Equal(2, eliminated, "%v", progressive)
+	}
+}
+
+func TestTransferEmptyTable(t *testing.T) {
+	assert := assert.New(t)
+	to := NewHoldem("to", 2, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop())
+	var g *testGame
+	g = newTestGame(t, 10, []uint{1000, 1000}, func(s *HoldemState) {
+		//所有人都换桌走了
+		if s.HandNum == 1 {
+			assert.Nil(g.h.Transfer("p1", to, 0))
+			assert.Nil(g.h.Transfer("p2", to, 0))
+		}
+	}, OptionWaitForNotEnoughPlayers(10*time.Millisecond))
+	g.start()
+	g.play(&Bet{Action: ActionDefFold})
+	for i := 0; i < 5000; i++ {
+		if g.agents[0].h == to && g.agents[1].h == to {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	//空桌子等待人数足够,可以正常结束
+	g.h.Stop()
+	g.wait()
+	assert.Equal(1, len(g.rec.results))
+	to.seatLock.Lock()
+	defer to.seatLock.Unlock()
+	var total uint
+	for _, r := range to.players {
+		total += r.gameInfo.chip
+	}
+	assert.Equal(2, len(to.players))
+	assert.Equal(uint(2000), total)
+}
+
+func TestRake(t *testing.T) {
+	assert := assert.New(t)
+	fold := &Bet{Action: ActionDefFold}
+	check := &Bet{Action: ActionDefCheck}
+	call := &Bet{Action: ActionDefCall}
+	//翻牌前都跟注后每条街都过牌,底池60
+	showdown := []*Bet{call, call, check, check, check, check, check, check, check, check, check, check}
+	cases := []struct {
+		name   string
+		policy *RakePolicy
+		chips  []uint
+		bets   []*Bet
+		rake   uint
+	}{
+		{"no flop no drop", &RakePolicy{Rate: 0.1, NoFlopNoDrop: true}, []uint{1000, 1000, 1000}, []*Bet{{Action: ActionDefRaise, Num: 100}, fold, fold}, 0},
+		//没有跟注的80不抽
+		{"uncalled bet", &RakePolicy{Rate: 0.1}, []uint{1000, 1000, 1000}, []*Bet{{Action: ActionDefRaise, Num: 100}, fold, fold}, 5},
+		{"showdown", &RakePolicy{Rate: 0.1, NoFlopNoDrop: true}, []uint{1000, 1000, 1000}, showdown, 6},
+		{"cap", &RakePolicy{Rate: 0.1, Cap: 5}, []uint{1000, 1000, 1000}, showdown, 5},
+		//按发牌人数封顶
+		{"caps by players", &RakePolicy{Rate: 0.1, Cap: 5, Caps: map[int8]uint{2: 3, 3: 4, 6: 1}}, []uint{1000, 1000, 1000}, showdown, 4},
+		//主池900,边池1400
+		{"side pots", &RakePolicy{Rate: 0.1}, []uint{1000, 1000, 300}, nil, 230},
+		{"main pot only", &RakePolicy{Rate: 0.1, MainPotOnly: true}, []uint{1000, 1000, 300}, nil, 90},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, cs.chips, nil, OptionRake(cs.policy))
+		g.start()
+		if cs.bets == nil {
+			g.auto(testAllIn)
+		} else {
+			assert.Equal(make([]int, len(cs.bets)), g.play(cs.bets...), cs.name)
+			g.wait()
+		}
+		var rake, total, sum uint
+		for _, r := range g.rec.results[0] {
+			rake += r.Rake
+			total += r.Chip
+			if r.Rake > 0 {
+				assert.True(r.Num > 0, cs.name)
+			}
+		}
+		for _, chip := range cs.chips {
+			sum += chip
+		}
+		assert.Equal(cs.rake, rake, cs.name)
+		assert.Equal(sum-cs.rake, total, cs.name)
+	}
+}
+
+//testLedgerRecorder 额外记录筹码不守恒
+type testLedgerRecorder struct {
+	*testRecorder
+	violations []*LedgerViolation
+}
+
+func (c *testLedgerRecorder) LedgerViolation(base *HoldemBase, violations []*LedgerViolation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.violations = append(c.violations, violations...)
+}
+
+func TestLedger(t *testing.T) {
+	assert := assert.New(t)
+	var g *testGame
+	g = newTestGame(t, 3, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+		switch s.HandNum {
+		case 1:
+			//坐着补充筹码要入账
+			g.agents[0].BringIn(100)
+		case 2:
+			//不经过账本改筹码
+			g.agents[1].gameInfo.chip += 50
+		}
+	})
+	rec := &testLedgerRecorder{testRecorder: g.rec}
+	g.h.options.recorder = rec
+	g.start()
+	g.auto(func(a *Agent) *Bet {
+		if a.gameInfo.roundBet < g.h.roundBet {
+			return &Bet{Action: ActionDefCall, Num: g.h.roundBet - a.gameInfo.roundBet}
+		}
+		return &Bet{Action: ActionDefCheck}
+	})
+	if !assert.Equal(2, len(rec.violations)) {
+		return
+	}
+	for i, account := range []string{playerAccount("p2"), accountCashier} {
+		assert.Equal(uint(3), rec.violations[i].HandNum)
+		assert.Equal(account, rec.violations[i].Account)
+	}
+	//玩家账户少了50,带入少了50(筹码多了50)
+	assert.Equal(int64(-50), rec.violations[0].Expect-rec.violations[0].Actual)
+	assert.Equal(int64(50), rec.violations[1].Expect-rec.violations[1].Actual)
+}
+
+func TestBuyIn(t *testing.T) {
+	assert := assert.New(t)
+	//大盲20,最小带入1000,最大2000
+	cases := []struct {
+		name  string
+		chips []uint
+		code  int
+		chip  uint
+	}{
+		{"below min", []uint{500}, ErrCodeBuyInTooLittle, 0},
+		{"above max", []uint{3000}, 0, 2000},
+		//补码时不受最小带入限制
+		{"top up", []uint{1000, 100}, 0, 1100},
+		{"top up above max", []uint{1500, 1000}, 0, 2000},
+		{"already at max", []uint{2000, 100}, ErrCodeBuyInTooMuch, 2000},
+	}
+	for _, cs := range cases {
+		g := newTestGame(t, 1, []uint{0, 0}, nil, OptionBuyIn(50, 100))
+		a := g.agents[0]
+		for i, chip := range cs.chips {
+			a.BringIn(chip)
+			//第一次带入后坐下
+			if i == 0 && a.gameInfo != nil {
+				a.Seated(1)
+			}
+		}
+		_, code := g.players[0].lastErr()
+		assert.Equal(cs.code, code, cs.name)
+		var chip uint
+		if a.gameInfo != nil {
+			chip = a.gameInfo.chip
+		}
+		assert.Equal(cs.chip, chip, cs.name)
+	}
+}
+
+func TestBuyInBetweenHands(t *testing.T) {
+	assert := assert.New(t)
+	fold := &Bet{Action: ActionDefFold}
+	//最大带入1200,stack为手中带入时的筹码,chip为这手结束时的筹码
+	cases := []struct {
+		name    string
+		bringIn uint
+		topUp   uint
+		pending func(stack uint) uint
+		expect  func(stack uint, chip uint) uint
+	}{
+		//手中带入等这手结束后加上
+		{"queued", 100, 0, func(stack uint) uint { return 100 }, func(stack uint, chip uint) uint { return chip + 100 }},
+		//手中带入超过最大带入的部分不带入
+		{"queued above max", 1000, 0, func(stack uint) uint { return 1200 - stack }, func(stack uint, chip uint) uint { return chip + 1200 - stack }},
+		{"auto top up", 0, 55, func(stack uint) uint { return 0 }, func(stack uint, chip uint) uint { return 1100 }},
+		{"auto top up to max", 0, 100, func(stack uint) uint { return 0 }, func(stack uint, chip uint) uint { return 1200 }},
+	}
+	for _, cs := range cases {
+		var g *testGame
+		var chip, pending uint
+		g = newTestGame(t, 2, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+			if s.HandNum == 1 {
+				chip = g.agents[0].gameInfo.chip
+				pending = g.agents[0].gameInfo.pendingChip
+			}
+		}, OptionBuyIn(0, 60))
+		g.start()
+		g.turn()
+		stack := g.agents[0].gameInfo.chip
+		if cs.bringIn > 0 {
+			g.agents[0].BringIn(cs.bringIn)
+			//这手中筹码不变
+			assert.Equal(stack, g.agents[0].gameInfo.chip, cs.name)
+		}
+		if cs.topUp > 0 {
+			g.agents[0].AutoTopUp(cs.topUp)
+		}
+		assert.Equal(make([]int, 4), g.play(fold, fold, fold, fold), cs.name)
+		g.wait()
+		_, code := g.players[0].lastErr()
+		assert.Equal(0, code, cs.name)
+		assert.Equal(cs.pending(stack), pending, cs.name)
+		assert.Equal(cs.expect(stack, chip), g.rec.chip(1, 1), cs.name)
+	}
+}
+
+func TestRathole(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		name    string
+		window  time.Duration
+		bringIn uint
+		code    int
+	}{
+		{"less", time.Minute, 1000, ErrCodeBuyInRathole},
+		//可以超过最大带入
+		{"same", time.Minute, 1010, 0},
+		{"expired", time.Millisecond, 1000, 0},
+	}
+	fold := &Bet{Action: ActionDefFold}
+	for _, cs := range cases {
+		var g *testGame
+		var winner int
+		var seat int8
+		codes := make([]int, 0)
+		//最大带入1000,第一手大盲赢10后站起,第二手结束后重新坐下
+		g = newTestGame(t, 3, []uint{1000, 1000, 1000}, func(s *HoldemState) {
+			switch s.HandNum {
+			case 1:
+				for i, a := range g.agents {
+					if a.gameInfo.chip > g.agents[winner].gameInfo.chip {
+						winner = i
+					}
+				}
+				seat = g.agents[winner].gameInfo.seatNumber
+				g.agents[winner].StandUp()
+			case 2:
+				a, p := g.agents[winner], g.players[winner]
+				n, _ := p.lastErr()
+				a.BringIn(cs.bringIn)
+				if cnt, _ := p.lastErr(); cnt == n {
+					a.Seated(seat)
+				}
+				code := 0
+				if cnt, last := p.lastErr(); cnt > n {
+					code = last
+				}
+				codes = append(codes, code)
+			}
+		}, OptionBuyIn(0, 50), OptionRathole(cs.window))
+		g.start()
+		assert.Equal(make([]int, 2), g.play(fold, fold), cs.name)
+		g.auto(func(a *Agent) *Bet { return fold })
+		assert.Equal([]int{cs.code}, codes, cs.name)
+		if cs.code != 0 {
+			assert.Nil(g.agents[winner].gameInfo, cs.name)
+			continue
+		}
+		//重新坐下后继续游戏
+		assert.Equal(cs.bringIn, g.rec.chip(2, seat), cs.name)
+	}
+}
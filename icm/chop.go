@@ -0,0 +1,137 @@
+package icm
+
+import (
+	"math"
+	"sort"
+)
+
+//ChopType 分奖方式
+type ChopType int8
+
+const (
+	//ChopICM 按ICM期望分
+	ChopICM ChopType = iota
+	//ChopChip 每人先拿剩余的最低奖金,其余按筹码比例分
+	ChopChip
+)
+
+func (c ChopType) String() string {
+	switch c {
+	case ChopICM:
+		return "icm"
+	case ChopChip:
+		return "chip"
+	}
+	return "unknown"
+}
+
+//Proposal 分奖提议
+type Proposal struct {
+	Type ChopType
+	//Stacks 提议时的筹码
+	Stacks []uint
+	//Equities 每个玩家的奖金期望
+	Equities []float64
+	//Amounts 每个玩家分到的奖金(总和等于剩余名次的奖金之和)
+	Amounts []uint
+}
+
+//Chop 计算分奖提议(payouts为剩余名次的奖金,第1名开始,超过人数的名次忽略)
+func Chop(t ChopType, stacks []uint, payouts []uint) (*Proposal, error) {
+	n := len(stacks)
+	if n == 0 {
+		return nil, ErrNoPlayers
+	}
+	if len(payouts) > n {
+		payouts = payouts[:n]
+	}
+	pf := make([]float64, len(payouts))
+	for i, v := range payouts {
+		pf[i] = float64(v)
+	}
+	var eq []float64
+	var err error
+	switch t {
+	case ChopICM:
+		eq, err = Equity(stacks, pf)
+	case ChopChip:
+		eq, err = chipChop(stacks, pf)
+	default:
+		err = ErrInvalidChopType
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Proposal{
+		Type:     t,
+		Stacks:   stacks,
+		Equities: eq,
+		Amounts:  round(eq, stacks),
+	}, nil
+}
+
+//Proposals ICM和筹码两种分奖提议
+func Proposals(stacks []uint, payouts []uint) ([]*Proposal, error) {
+	ret := make([]*Proposal, 0, 2)
+	for _, t := range []ChopType{ChopICM, ChopChip} {
+		p, err := Chop(t, stacks, payouts)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+//chipChop 每人先拿剩余的最低奖金,其余按筹码比例分
+func chipChop(stacks []uint, payouts []float64) ([]float64, error) {
+	n := len(stacks)
+	var min float64
+	if len(payouts) == n {
+		min = payouts[n-1]
+	}
+	rest := make([]float64, len(payouts))
+	for i, v := range payouts {
+		rest[i] = v - min
+	}
+	ret, err := ChipEV(stacks, rest)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ret {
+		ret[i] += min
+	}
+	return ret, nil
+}
+
+//round 取整并保证总和不变(余数按小数部分从大到小补,相同时筹码多的优先)
+func round(eq []float64, stacks []uint) []uint {
+	var total float64
+	for _, v := range eq {
+		total += v
+	}
+	prize := uint(math.Round(total))
+	ret := make([]uint, len(eq))
+	var sum uint
+	for i, v := range eq {
+		ret[i] = uint(math.Floor(v + 1e-9))
+		sum += ret[i]
+	}
+	idx := make([]int, len(eq))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		fa := eq[idx[a]] - float64(ret[idx[a]])
+		fb := eq[idx[b]] - float64(ret[idx[b]])
+		if fa != fb {
+			return fa > fb
+		}
+		return stacks[idx[a]] > stacks[idx[b]]
+	})
+	for i := 0; sum < prize && i < len(idx); i++ {
+		ret[idx[i]]++
+		sum++
+	}
+	return ret
+}
@@ -0,0 +1,10 @@
+package icm
+
+import "errors"
+
+var (
+	ErrNoPlayers       = errors.New("no players")
+	ErrNoChips         = errors.New("no chips")
+	ErrTooManyPlayers  = errors.New("too many players")
+	ErrInvalidChopType = errors.New("invalid chop type")
+)
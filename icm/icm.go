@@ -0,0 +1,121 @@
+package icm
+
+//MaxPlayers 最多计算的玩家数(玩家集合用32位掩码)
+const MaxPlayers = 30
+
+//MaxStates 每个名次最多展开的玩家集合数,超过后剩下的名次按筹码比例分配
+const MaxStates = 1 << 16
+
+//Equity Malmstrom-Harville ICM 每个玩家的奖金期望
+//每个名次由剩下的玩家按筹码比例获得,payouts为第1名开始的奖金,筹码为0的玩家期望为0
+//名次展开的集合数超过MaxStates时,更后面名次的奖金按剩下玩家的筹码比例分配(ChipEV)
+func Equity(stacks []uint, payouts []float64) ([]float64, error) {
+	n := len(stacks)
+	if n == 0 {
+		return nil, ErrNoPlayers
+	}
+	if n > MaxPlayers {
+		return nil, ErrTooManyPlayers
+	}
+	var total uint64
+	alive := 0
+	for _, s := range stacks {
+		total += uint64(s)
+		if s > 0 {
+			alive++
+		}
+	}
+	if total == 0 {
+		return nil, ErrNoChips
+	}
+	places := len(payouts)
+	if places > alive {
+		places = alive
+	}
+	depth := depthOf(alive, places)
+	ret := make([]float64, n)
+	//已经拿到前几名的玩家集合(位掩码) -> 出现的概率
+	level := map[uint32]float64{0: 1}
+	for place := 0; place < depth; place++ {
+		next := make(map[uint32]float64, len(level)*(n-place))
+		for mask, p := range level {
+			var used uint64
+			for i := 0; i < n; i++ {
+				if mask&(1<<uint(i)) != 0 {
+					used += uint64(stacks[i])
+				}
+			}
+			left := float64(total - used)
+			for i := 0; i < n; i++ {
+				if mask&(1<<uint(i)) != 0 || stacks[i] == 0 {
+					continue
+				}
+				q := p * float64(stacks[i]) / left
+				ret[i] += q * payouts[place]
+				next[mask|1<<uint(i)] += q
+			}
+		}
+		level = next
+	}
+	if depth == places {
+		return ret, nil
+	}
+	var rest float64
+	for _, v := range payouts[depth:places] {
+		rest += v
+	}
+	for mask, p := range level {
+		var used uint64
+		for i := 0; i < n; i++ {
+			if mask&(1<<uint(i)) != 0 {
+				used += uint64(stacks[i])
+			}
+		}
+		left := float64(total - used)
+		for i := 0; i < n; i++ {
+			if mask&(1<<uint(i)) == 0 {
+				ret[i] += p * rest * float64(stacks[i]) / left
+			}
+		}
+	}
+	return ret, nil
+}
+
+//depthOf 每层集合数(C(alive,k))不超过MaxStates时能展开的名次数
+func depthOf(alive int, places int) int {
+	states := 1.0
+	for k := 0; k < places; k++ {
+		states = states * float64(alive-k) / float64(k+1)
+		if states > MaxStates {
+			return k
+		}
+	}
+	return places
+}
+
+//ChipEV 按筹码比例的奖金期望(总奖金按筹码占比分配)
+func ChipEV(stacks []uint, payouts []float64) ([]float64, error) {
+	n := len(stacks)
+	if n == 0 {
+		return nil, ErrNoPlayers
+	}
+	var total uint64
+	for _, s := range stacks {
+		total += uint64(s)
+	}
+	if total == 0 {
+		return nil, ErrNoChips
+	}
+	var prize float64
+	for i, v := range payouts {
+		if i >= n {
+			break
+		}
+		prize += v
+	}
+	ret := make([]float64, n)
+	for i, s := range stacks {
+		ret[i] = prize * float64(s) / float64(total)
+	}
+	return ret, nil
+}
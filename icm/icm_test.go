@@ -0,0 +1,92 @@
+package icm
+
+import (
+	"testing"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestEquity(t *testing.T) {
+	//单挑时等于筹码比例
+	eq, err := Equity([]uint{3000, 1000}, []float64{100, 50})
+	assert.Nil(t, err)
+	assert.InDelta(t, 87.5, eq[0], 1e-9)
+	assert.InDelta(t, 62.5, eq[1], 1e-9)
+	//筹码相同期望相同
+	eq, err = Equity([]uint{100, 100, 100}, []float64{50, 30, 20})
+	assert.Nil(t, err)
+	for _, v := range eq {
+		assert.InDelta(t, 100.0/3, v, 1e-9)
+	}
+	//经典例子
+	eq, err = Equity([]uint{50, 30, 20}, []float64{0.5, 0.3, 0.2})
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.383929, eq[0], 1e-6)
+	assert.InDelta(t, 0.3275, eq[1], 1e-6)
+	assert.InDelta(t, 0.288571, eq[2], 1e-6)
+	//没有筹码的期望为0,奖金不超过剩下的人数
+	eq, err = Equity([]uint{200, 0, 100}, []float64{60, 30, 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, eq[1])
+	assert.InDelta(t, 90, eq[0]+eq[2], 1e-9)
+	_, err = Equity(nil, []float64{1})
+	assert.Equal(t, ErrNoPlayers, err)
+	_, err = Equity([]uint{0, 0}, []float64{1})
+	assert.Equal(t, ErrNoChips, err)
+}
+
+func TestEquityDepthCap(t *testing.T) {
+	assert.Equal(t, 3, depthOf(3, 3))
+	assert.Equal(t, 4, depthOf(30, 30))
+	//30人全部有奖金,超过展开层数的名次按筹码比例
+	stacks := make([]uint, 30)
+	payouts := make([]float64, 30)
+	var prize float64
+	for i := range stacks {
+		stacks[i] = uint(i+1) * 100
+		payouts[i] = float64(30 - i)
+		prize += payouts[i]
+	}
+	eq, err := Equity(stacks, payouts)
+	assert.Nil(t, err)
+	var sum float64
+	for i, v := range eq {
+		sum += v
+		assert.True(t, v >= 1 && v <= 30)
+		if i > 0 {
+			assert.True(t, v > eq[i-1])
+		}
+	}
+	assert.InDelta(t, prize, sum, 1e-6)
+	for i := range stacks {
+		stacks[i] = 1000
+	}
+	eq, err = Equity(stacks, payouts)
+	assert.Nil(t, err)
+	for _, v := range eq {
+		assert.InDelta(t, prize/30, v, 1e-9)
+	}
+}
+
+func TestChop(t *testing.T) {
+	stacks := []uint{5000, 3000, 1500, 500}
+	payouts := []uint{400, 250, 150, 100, 60}
+	ps, err := Proposals(stacks, payouts)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(ps))
+	for _, p := range ps {
+		var sum uint
+		for _, v := range p.Amounts {
+			sum += v
+		}
+		//只分配剩余人数的名次
+		assert.Equal(t, uint(900), sum, p.Type.String())
+	}
+	//筹码分奖: 每人先拿100,剩下500按筹码比例
+	assert.Equal(t, []uint{350, 250, 175, 125}, ps[1].Amounts)
+	//ICM 比筹码分奖对短码更有利
+	assert.True(t, ps[0].Amounts[3] > ps[1].Amounts[3])
+	assert.True(t, ps[0].Amounts[0] < ps[1].Amounts[0])
+	_, err = Chop(ChopType(9), stacks, payouts)
+	assert.Equal(t, ErrInvalidChopType, err)
+}
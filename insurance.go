@@ -25,8 +25,8 @@ func (c *Holdem) insuranceStart(users []*Agent, round Round) {
 		us[u.gameInfo.seatNumber] = u
 	}
 	pots := c.calcPot(users)
-	currentHands, allNextHands := GetAllOuts(c.publicCards, cardsMap)
-	leaderOuts := GetOuts(currentHands, allNextHands, pots)
+	//按玩法计算(奥马哈必须用2张手牌)
+	leaderOuts := fastOuts(c.poker.deck, c.publicCards, cardsMap, pots, c.fastHandValue, c.maxHandValue)
 	grp, _ := errgroup.WithContext(context.Background())
 	ch := make(chan *InsuranceResult, len(users))
 	c.insuranceUsers = make([]*Agent, 0)
@@ -59,7 +59,9 @@ func (c *Holdem) insuranceStart(users []*Agent, round Round) {
 		for seat, hvs := range o.Detail {
 			userOuts[seat] = make([]*UserOut, 0)
 			for cd, hv := range hvs {
-				cds := append(c.publicCards, u.gameInfo.cards...)
+				cds := make([]*Card, 0, len(c.publicCards)+len(cardsMap[seat])+1)
+				cds = append(cds, c.publicCards...)
+				cds = append(cds, cardsMap[seat]...)
 				cds = append(cds, cd)
 				mp := hv.TaggingCards(cds)
 				cr := make([]*CardResult, 0)
@@ -0,0 +1,257 @@
+package holdem
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+//JackpotPolicy 坏牌奖池规则
+type JackpotPolicy struct {
+	//Drop 每手从主池扣除注入奖池的数量(和抽水一样,没有翻牌不扣)
+	Drop uint
+	//MinPot 主池不少于这个数量才扣除
+	MinPot uint
+	//MinHand 输掉的牌最少要的牌型(例如HVFourOfAKind)
+	MinHand HandValueType
+	//BothHoleCards 输掉的牌必须两张手牌都用上
+	BothHoleCards bool
+	//LoserShare 输家分得奖池的百分比
+	LoserShare uint
+	//WinnerShare 赢家分得奖池的百分比
+	WinnerShare uint
+	//TableShare 桌上其他发了牌的玩家平分奖池的百分比(剩下的留在奖池里)
+	TableShare uint
+}
+
+//Jackpot 坏牌奖池(可以多张桌子共用)
+type Jackpot struct {
+	policy *JackpotPolicy
+	mu     sync.Mutex
+	amount uint
+}
+
+//NewJackpot 创建奖池(初始金额)
+func NewJackpot(policy *JackpotPolicy, seed uint) *Jackpot {
+	return &Jackpot{
+		policy: policy,
+		amount: seed,
+	}
+}
+
+//Amount 当前奖池金额
+func (c *Jackpot) Amount() uint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.amount
+}
+
+//add 注入奖池
+func (c *Jackpot) add(num uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.amount += num
+}
+
+//hit 中奖时按比例取出输家/赢家/其他玩家(没有其他玩家时留在奖池)的部分,并返回剩余奖池
+func (c *Jackpot) hit(others bool) (uint, uint, uint, uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.policy
+	loser := c.amount * p.LoserShare / 100
+	winner := c.amount * p.WinnerShare / 100
+	var table uint
+	if others {
+		table = c.amount * p.TableShare / 100
+	}
+	c.amount -= loser + winner + table
+	return loser, winner, table, c.amount
+}
+
+//JackpotRole 奖池中奖的身份
+type JackpotRole int8
+
+const (
+	//JackpotLoser 输家
+	JackpotLoser JackpotRole = iota + 1
+	//JackpotWinner 赢家
+	JackpotWinner
+	//JackpotTable 桌上其他玩家
+	JackpotTable
+)
+
+//JackpotAward 奖池发奖(直接加到筹码上)
+type JackpotAward struct {
+	SeatNumber int8
+	UserID     string
+	Role       JackpotRole
+	Amount     uint
+}
+
+//JackpotHit 奖池中奖
+type JackpotHit struct {
+	//LoserHand 输掉的牌
+	LoserHand HandValueType
+	//WinnerHand 赢的牌
+	WinnerHand HandValueType
+	Awards     []*JackpotAward
+	//Left 发奖后奖池剩余
+	Left uint
+}
+
+//jackpotDrop 主池(已经抽水)中注入奖池的数量
+func (c *Holdem) jackpotDrop(num uint) uint {
+	j := c.options.jackpot
+	if j == nil || j.policy.Drop == 0 || len(c.publicCards) == 0 || num < j.policy.MinPot {
+		return 0
+	}
+	drop := j.policy.Drop
+	if drop > num {
+		drop = num
+	}
+	c.jackpotDropped = drop
+	j.add(drop)
+	c.log.Debug("jackpot drop", zap.Uint("drop", drop))
+	return drop
+}
+
+//qualify 输掉的牌是否符合条件
+func (c *JackpotPolicy) qualify(u *Agent) bool {
+	return qualifyHand(u, c.MinHand, c.BothHoleCards)
+}
+
+//qualifyHand 比牌的牌型是否不小于min,both时两张手牌都要用上(手牌在CardResult的最后)
+func qualifyHand(u *Agent, min HandValueType, both bool) bool {
+	hv := u.gameInfo.handValue
+	if hv == nil || hv.MaxHandValueType() < min {
+		return false
+	}
+	if !both {
+		return true
+	}
+	crs := u.gameInfo.cardResults
+	n := len(u.gameInfo.cards)
+	if n < 2 || len(crs) < n {
+		return false
+	}
+	selected := 0
+	for _, cr := range crs[len(crs)-n:] {
+		if cr.Selected {
+			selected++
+		}
+	}
+	return selected >= 2
+}
+
+//checkJackpot 比牌后检查是否中奖(只看一次发牌,主池的赢家和输掉主池的最大牌),中奖时加筹码并返回每个座位的奖金
+func (c *Holdem) checkJackpot(users []*Agent, mainWins map[int8]uint) (*JackpotHit, map[int8]uint) {
+	j := c.options.jackpot
+	if j == nil {
+		return nil, nil
+	}
+	var winner, loser *Agent
+	for _, u := range users {
+		if _, ok := mainWins[u.gameInfo.seatNumber]; ok {
+			if winner == nil {
+				winner = u
+			}
+			continue
+		}
+		if !j.policy.qualify(u) {
+			continue
+		}
+		if loser == nil || u.gameInfo.handValue.Value() > loser.gameInfo.handValue.Value() {
+			loser = u
+		}
+	}
+	if winner == nil || loser == nil {
+		return nil, nil
+	}
+	//其他发了牌的玩家(包括盖牌的),从庄位后第一个开始
+	others := make([]*Agent, 0)
+	u := c.button.nextAgent
+	for {
+		if u.id != loser.id && u.id != winner.id && len(u.gameInfo.cards) > 0 {
+			others = append(others, u)
+		}
+		if u == c.button {
+			break
+		}
+		u = u.nextAgent
+	}
+	loserShare, winnerShare, tableShare, left := j.hit(len(others) > 0)
+	hit := &JackpotHit{
+		LoserHand:  loser.gameInfo.handValue.MaxHandValueType(),
+		WinnerHand: winner.gameInfo.handValue.MaxHandValueType(),
+		Awards:     make([]*JackpotAward, 0),
+		Left:       left,
+	}
+	wins := make(map[int8]uint)
+	award := func(u *Agent, role JackpotRole, num uint) {
+		u.gameInfo.chip += num
+		c.transferChip(playerAccount(u.id), accountJackpot, num, "jackpot")
+		wins[u.gameInfo.seatNumber] += num
+		hit.Awards = append(hit.Awards, &JackpotAward{
+			SeatNumber: u.gameInfo.seatNumber,
+			UserID:     u.id,
+			Role:       role,
+			Amount:     num,
+		})
+	}
+	award(loser, JackpotLoser, loserShare)
+	award(winner, JackpotWinner, winnerShare)
+	//其他玩家平分,零头给庄位后第一个
+	if len(others) > 0 {
+		share := tableShare / uint(len(others))
+		for i, o := range others {
+			num := share
+			if i == 0 {
+				num += tableShare - share*uint(len(others))
+			}
+			award(o, JackpotTable, num)
+		}
+	}
+	c.log.Debug("jackpot hit", zap.String("loser", loser.id), zap.String("winner", winner.id), zap.Any("awards", hit.Awards))
+	return hit, wins
+}
+
+//HighHandPolicy 高牌型促销(只通知,奖金由平台发放)
+type HighHandPolicy struct {
+	//MinHand 比牌时最少要的牌型(例如HVFourOfAKind)
+	MinHand HandValueType
+	//BothHoleCards 两张手牌都要用上
+	BothHoleCards bool
+}
+
+//HighHand 达到高牌型促销的牌
+type HighHand struct {
+	SeatNumber    int8
+	UserID        string
+	HandValueType HandValueType
+	//Value 牌型大小(可以比较同一时段各桌最大的牌)
+	Value int64
+	Cards []*CardResult
+}
+
+//checkHighHands 比牌后达到高牌型促销的玩家(多次发牌时看第一次)
+func (c *Holdem) checkHighHands(users []*Agent) []*HighHand {
+	p := c.options.highHand
+	if p == nil {
+		return nil
+	}
+	ret := make([]*HighHand, 0)
+	for _, u := range users {
+		if !qualifyHand(u, p.MinHand, p.BothHoleCards) {
+			continue
+		}
+		hv := u.gameInfo.handValue
+		ret = append(ret, &HighHand{
+			SeatNumber:    u.gameInfo.seatNumber,
+			UserID:        u.id,
+			HandValueType: hv.MaxHandValueType(),
+			Value:         hv.Value(),
+			Cards:         u.gameInfo.cardResults,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,121 @@
+package holdem
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+//账本中的账户(玩家的账户为player:用户ID)
+const (
+	accountCashier = "cashier" //带入/带出
+	accountPot     = "pot"     //底池
+	accountRake    = "rake"    //抽水
+	accountJackpot = "jackpot" //坏牌奖池
+	accountHouse   = "house"   //看剩余公共牌等费用
+)
+
+//playerAccount 玩家的账户
+func playerAccount(id string) string {
+	return "player:" + id
+}
+
+//LedgerEntry 一笔筹码转移(Debit增加,Credit减少)
+type LedgerEntry struct {
+	HandNum uint
+	Debit   string
+	Credit  string
+	Amount  uint
+	Memo    string
+}
+
+//LedgerViolation 筹码不守恒(账户上应有的和实际的数量)
+type LedgerViolation struct {
+	HandNum uint
+	Account string
+	Expect  int64
+	Actual  int64
+}
+
+//ledger 筹码复式记账
+type ledger struct {
+	mu       sync.Mutex
+	balances map[string]int64
+	entries  []*LedgerEntry //上次检查后的记录
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		balances: make(map[string]int64),
+		entries:  make([]*LedgerEntry, 0),
+	}
+}
+
+//post 记一笔转移
+func (c *ledger) post(hand uint, debit string, credit string, amount uint, memo string) {
+	if amount == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balances[debit] += int64(amount)
+	c.balances[credit] -= int64(amount)
+	c.entries = append(c.entries, &LedgerEntry{
+		HandNum: hand,
+		Debit:   debit,
+		Credit:  credit,
+		Amount:  amount,
+		Memo:    memo,
+	})
+}
+
+//balance 账户余额
+func (c *ledger) balance(account string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.balances[account]
+}
+
+//flush 取出上次检查后的记录
+func (c *ledger) flush() []*LedgerEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := c.entries
+	c.entries = make([]*LedgerEntry, 0)
+	return ret
+}
+
+//transferChip 记一笔转移(本手)
+func (c *Holdem) transferChip(debit string, credit string, amount uint, memo string) {
+	c.ledger.post(c.handNum, debit, credit, amount, memo)
+}
+
+//checkLedger 每手结束后检查筹码守恒(有锁)
+//每个玩家的账户等于筹码,底池等于c.pot,带入减带出等于筹码加底池加抽水/奖池/费用
+func (c *Holdem) checkLedger() {
+	violations := make([]*LedgerViolation, 0)
+	check := func(account string, actual int64) {
+		if expect := c.ledger.balance(account); expect != actual {
+			violations = append(violations, &LedgerViolation{
+				HandNum: c.handNum,
+				Account: account,
+				Expect:  expect,
+				Actual:  actual,
+			})
+		}
+	}
+	var stacks int64
+	for _, r := range c.players {
+		stacks += int64(r.gameInfo.chip)
+		check(playerAccount(r.id), int64(r.gameInfo.chip))
+	}
+	check(accountPot, int64(c.pot))
+	held := stacks + int64(c.pot) + c.ledger.balance(accountRake) + c.ledger.balance(accountJackpot) + c.ledger.balance(accountHouse)
+	check(accountCashier, -held)
+	entries := c.ledger.flush()
+	if len(violations) == 0 {
+		return
+	}
+	c.log.Error("ledger violation", zap.Any("violations", violations), zap.Any("entries", entries))
+	c.options.recorder.LedgerViolation(c.base(), violations)
+}
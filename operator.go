@@ -21,21 +21,39 @@ type Operator struct {
 	MinRaise uint
 	//CurrentTableBet 当前轮桌上下注额
 	CurrentTableBet uint
+	//MinAmount 下注/加注本次最少投入(根据下注结构,0为不能下注/加注)
+	MinAmount uint
+	//MaxAmount 下注/加注本次最多投入(根据下注结构,0为不能下注/加注)
+	MaxAmount uint
 }
 
-func newOperator(r *Agent, bet uint, minRaise uint, wait time.Duration) *Operator {
+//newOperator 操作者信息(包括当前下注结构的下注范围)
+func (c *Holdem) newOperator(r *Agent, round Round) *Operator {
 	if r == nil {
 		return nil
 	}
-	return &Operator{
+	s := c.betSituation(r, round)
+	op := &Operator{
 		ID:              r.ID(),
-		Wait:            wait,
+		Wait:            c.waitBetTimeout,
 		SeatNumber:      r.gameInfo.seatNumber,
 		Chip:            r.gameInfo.chip,
 		BringIn:         r.gameInfo.bringIn,
 		HandBet:         r.gameInfo.handBet,
 		RoundBet:        r.gameInfo.roundBet,
-		MinRaise:        minRaise,
-		CurrentTableBet: bet,
+		MinRaise:        c.minRaise,
+		CurrentTableBet: c.roundBet,
 	}
+	min, max, ok := c.options.betting.Limit(s)
+	if ok && s.Chip > s.Call() {
+		if max > s.Chip {
+			max = s.Chip
+		}
+		if min > max {
+			min = max
+		}
+		op.MinAmount = min
+		op.MaxAmount = max
+	}
+	return op
 }
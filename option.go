@@ -1,6 +1,7 @@
 package holdem
 
 import (
+	"math"
 	"time"
 )
 
@@ -17,10 +18,38 @@ type extOptions struct {
 	autoMinPlayers          int8 //最懂最少开始人数
 	minPlayers              int8 //最小游戏人数
 	delayStandUpTimeout     time.Duration
-	waitForNotEnoughPlayers time.Duration //人数不够等待时间
-	limitDelayTimes         uint          //延迟操作限制次数
-	limitAutoCheckTimes     uint          //自动check限制次数
-	limitAutoFoldTimes      uint          //自动flod限制次数
+	waitForNotEnoughPlayers time.Duration    //人数不够等待时间
+	limitDelayTimes         uint             //延迟操作限制次数
+	limitAutoCheckTimes     uint             //自动check限制次数
+	limitAutoFoldTimes      uint             //自动flod限制次数
+	holeCards               int              //手牌数量
+	omaha                   bool             //奥马哈(必须用2张手牌+3张公共牌)
+	betting                 BettingStructure //下注结构
+	shortDeck               bool             //短牌(6+)
+	ranking                 *HandRanking     //牌型大小规则
+	maxStraddles            int8             //最多连续抓头次数(0为不开启)
+	buttonStraddle          bool             //允许庄位抓头(Mississippi)
+	maxRunTimes             int8             //全下后最多发牌次数(小于2为不开启)
+	runWaitTimeout          time.Duration    //选择发牌次数等待时间
+	rabbitHunt              bool             //允许看剩余公共牌
+	rabbitHuntPrice         uint             //看剩余公共牌的价格
+	rabbitHuntLimit         uint             //每个玩家看剩余公共牌的次数限制(0为不限制)
+	allowShortStack         bool             //允许筹码不足一个大盲加前注的玩家坐下
+	sitAndGo                bool             //单桌赛
+	prizePool               uint             //单桌赛奖池
+	payouts                 []uint           //单桌赛名次奖励百分比(第1名开始)
+	blindSchedule           BlindSchedule    //盲注表
+	rebuyPolicy             *RebuyPolicy     //重购/加码/重新参赛规则
+	bounty                  uint             //每个玩家的初始赏金
+	progressiveBounty       bool             //渐进式赏金
+	rakePolicy              *RakePolicy      //抽水规则
+	jackpot                 *Jackpot         //坏牌奖池
+	highHand                *HighHandPolicy  //高牌型促销
+	minBuyIn                uint             //最小带入(大盲数,0为不限制)
+	maxBuyIn                uint             //最大带入/补码后的最大筹码(大盲数,0为不限制)
+	ratholeWindow           time.Duration    //离桌后这段时间内重新坐下要带回离桌时的筹码
+	deckSource              DeckSource       //洗牌来源
+	provablyFair            bool             //可验证公平模式
 }
 
 type HoldemOption interface {
@@ -122,3 +151,146 @@ func OptionLimitAutoFoldTimes(times uint) HoldemOption {
 		o.limitAutoFoldTimes = times
 	})
 }
+
+//OptionPotLimitOmaha 底池限注奥马哈(4张手牌,必须使用2张手牌+3张公共牌)
+func OptionPotLimitOmaha() HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.holeCards = 4
+		o.omaha = true
+		o.betting = &PotLimit{}
+	})
+}
+
+//OptionBettingStructure 下注结构(默认无限注)
+func OptionBettingStructure(bs BettingStructure) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.betting = bs
+	})
+}
+
+//OptionShortDeck 短牌(6+),可以指定牌型大小规则(默认同花大于葫芦)
+func OptionShortDeck(rk ...*HandRanking) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.shortDeck = true
+		o.ranking = ShortDeckHandRanking
+		if len(rk) > 0 && rk[0] != nil {
+			o.ranking = rk[0]
+		}
+	})
+}
+
+//OptionStraddle 开启抓头(max 最多连续抓头次数, button 是否允许庄位抓头)
+func OptionStraddle(max int8, button bool) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.maxStraddles = max
+		o.buttonStraddle = button
+	})
+}
+
+//OptionRunMultiple 全下后允许玩家同意多次发牌(最多maxTimes次,取所有人选择的最小值)
+func OptionRunMultiple(maxTimes int8, timeout time.Duration) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.maxRunTimes = maxTimes
+		o.runWaitTimeout = timeout
+	})
+}
+
+//OptionRabbitHunt 提前结束的一手可以看剩余的公共牌(价格,每个玩家次数限制0为不限制)
+func OptionRabbitHunt(price uint, limit uint) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.rabbitHunt = true
+		o.rabbitHuntPrice = price
+		o.rabbitHuntLimit = limit
+	})
+}
+
+//OptionAllowShortStack 允许筹码不足一个大盲加前注的玩家坐下(比赛换桌)
+func OptionAllowShortStack() HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.allowShortStack = true
+	})
+}
+
+//OptionSitAndGo 单桌赛(坐满自动开始,按级别升盲,输光淘汰,剩一人结束并按名次百分比分配奖池)
+func OptionSitAndGo(prizePool uint, payouts []uint, levels ...*BlindLevel) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.sitAndGo = true
+		o.prizePool = prizePool
+		o.payouts = payouts
+		o.blindSchedule = levels
+		o.autoStart = true
+		o.autoMinPlayers = math.MaxInt8
+	})
+}
+
+//OptionBlindSchedule 盲注表(每手开始前按手数/时间自动升级,休息级别暂停游戏)
+func OptionBlindSchedule(schedule BlindSchedule) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.blindSchedule = schedule
+	})
+}
+
+//OptionRebuy 重购/加码/重新参赛规则(单桌赛输光可以重购的玩家等待OptionWaitForRebuy的时间后才淘汰)
+func OptionRebuy(policy *RebuyPolicy) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.rebuyPolicy = policy
+	})
+}
+
+//OptionBounty 赏金(淘汰玩家获得他的赏金,渐进式时一半加到自己的赏金上)
+func OptionBounty(bounty uint, progressive bool) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.bounty = bounty
+		o.progressiveBounty = progressive
+	})
+}
+
+//OptionRake 抽水(分配前从池中扣除)
+func OptionRake(policy *RakePolicy) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.rakePolicy = policy
+	})
+}
+
+//OptionJackpot 坏牌奖池(多张桌子可以共用一个奖池)
+func OptionJackpot(jackpot *Jackpot) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.jackpot = jackpot
+	})
+}
+
+//OptionHighHand 高牌型促销(比牌时达到牌型的通知Recorder和所有人)
+func OptionHighHand(policy *HighHandPolicy) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.highHand = policy
+	})
+}
+
+//OptionBuyIn 带入限制(大盲数,0为不限制),第一次带入不少于min,带入/补码后筹码不超过max
+func OptionBuyIn(min uint, max uint) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.minBuyIn = min
+		o.maxBuyIn = max
+	})
+}
+
+//OptionRathole 离桌后window时间内重新坐下至少要带入离桌时的筹码(可以超过最大带入)
+func OptionRathole(window time.Duration) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.ratholeWindow = window
+	})
+}
+
+//OptionDeckSource 洗牌来源(固定种子/预先安排的牌序/自定义,默认用crypto/rand)
+func OptionDeckSource(source DeckSource) HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.deckSource = source
+	})
+}
+
+//OptionProvablyFair 可验证公平模式(接受玩家种子之前公布服务器种子和原始牌序的哈希,用两边的种子洗牌,结束后公开服务器种子)
+func OptionProvablyFair() HoldemOption {
+	return newFuncOption(func(o *extOptions) {
+		o.provablyFair = true
+	})
+}
@@ -1,60 +1,79 @@
 package holdem
 
 import (
-	"math/rand"
 	"sync"
-	"time"
 
 	"errors"
 )
 
 var pokerCards []*Card
+var shortPokerCards []*Card
 var smOnce sync.Once
 var ErrCardOutOfIndex = errors.New("left cards count is less than expect")
 var ErrInvalidCardLength = errors.New("unsupported card length")
+var ErrInvalidDeck = errors.New("invalid deck snapshot")
 
 type Poker struct {
 	cards        []*Card
 	currentIndex int
 	maxCards     int
+	deck         []*Card //原始顺序(每手从原始顺序开始洗牌,同样的洗牌来源得到同样的牌)
+	source       DeckSource
 }
 
 func init() {
 	pokerCards = make([]*Card, 0)
+	shortPokerCards = make([]*Card, 0)
 	smOnce.Do(func() {
 		var i, j int8
 		for j = 0; j < 4; j++ {
 			for i = 2; i <= 14; i++ {
-				pokerCards = append(pokerCards, &Card{
+				card := &Card{
 					Num:  i,
 					Suit: j,
-				})
+				}
+				pokerCards = append(pokerCards, card)
+				//短牌去掉2-5
+				if i >= 6 {
+					shortPokerCards = append(shortPokerCards, card)
+				}
 			}
 		}
 	})
 }
 
 func NewPoker() *Poker {
-	cards := make([]*Card, 0)
-	cards = append(cards, pokerCards...)
-	rd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	rd.Shuffle(len(cards), func(i int, j int) {
-		cards[i], cards[j] = cards[j], cards[i]
-	})
+	return newPoker(pokerCards)
+}
+
+//NewShortDeckPoker 短牌(6+) 36张
+func NewShortDeckPoker() *Poker {
+	return newPoker(shortPokerCards)
+}
+
+func newPoker(deck []*Card) *Poker {
+	cards := make([]*Card, 0, len(deck))
+	cards = append(cards, deck...)
+	source := &cryptoSource{}
+	source.Shuffle(cards)
+	source.Entropy()
 	return &Poker{
 		cards:        cards,
 		currentIndex: 0,
 		maxCards:     len(cards),
+		deck:         deck,
+		source:       source,
 	}
 }
 
-func newPokerWithExceptCardsAndNoShuffle(exceptCards []*Card) *Poker {
+//newPokerWithExceptCardsAndNoShuffle 从deck中去掉一些牌(不洗牌)
+func newPokerWithExceptCardsAndNoShuffle(deck []*Card, exceptCards []*Card) *Poker {
 	exceptMap := make(map[int8]bool)
 	for _, card := range exceptCards {
 		exceptMap[card.Value()] = true
 	}
 	cards := make([]*Card, 0)
-	for _, v := range pokerCards {
+	for _, v := range deck {
 		if _, ok := exceptMap[v.Value()]; !ok {
 			cards = append(cards, v)
 		}
@@ -63,24 +82,34 @@ func newPokerWithExceptCardsAndNoShuffle(exceptCards []*Card) *Poker {
 		cards:        cards,
 		currentIndex: 0,
 		maxCards:     len(cards),
+		deck:         append(make([]*Card, 0, len(cards)), cards...),
+		source:       &cryptoSource{},
 	}
 }
 
+//NewPokerWithoutCards 去掉死牌(已知的手牌/公共牌等)后洗好的牌
+func NewPokerWithoutCards(dead ...*Card) *Poker {
+	p := newPokerWithExceptCardsAndNoShuffle(pokerCards, dead)
+	p.Reset()
+	return p
+}
+
 func (c *Poker) Reset() {
-	rd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	rd.Shuffle(len(c.cards), func(i int, j int) {
-		c.cards[i], c.cards[j] = c.cards[j], c.cards[i]
-	})
+	copy(c.cards, c.deck)
+	c.source.Shuffle(c.cards)
+	c.currentIndex = 0
+}
+
+//arrange 按给定的顺序放牌(可验证公平模式)
+func (c *Poker) arrange(cards []*Card) {
+	copy(c.cards, cards)
 	c.currentIndex = 0
 }
 
 //ResetAfterOffset 在某个位置以后的牌重置
 func (c *Poker) ResetAfterOffset(offset int) {
 	offset = offset % len(c.cards)
-	rd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	rd.Shuffle(len(c.cards)-offset-1, func(i int, j int) {
-		c.cards[i+offset+1], c.cards[j+offset+1] = c.cards[j+offset+1], c.cards[i+offset+1]
-	})
+	c.source.Shuffle(c.cards[offset+1:])
 	c.currentIndex = offset + 1
 }
 
@@ -94,6 +123,109 @@ func (c *Poker) GetCards(n int) ([]*Card, error) {
 	return c.cards[c.currentIndex-n : c.currentIndex], nil
 }
 
+//peek 查看当前位置之后的牌(不移动位置)
+func (c *Poker) peek(offset int, n int) ([]*Card, error) {
+	t := c.currentIndex + offset
+	if t < 0 || t+n > c.maxCards {
+		return nil, ErrCardOutOfIndex
+	}
+	return c.cards[t : t+n], nil
+}
+
+//Peek 查看接下来的n张牌(不移动位置,返回的是复制的切片)
+func (c *Poker) Peek(n int) ([]*Card, error) {
+	cards, err := c.peek(0, n)
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]*Card, 0, n), cards...), nil
+}
+
+//Burn 烧掉n张牌
+func (c *Poker) Burn(n int) error {
+	_, err := c.GetCards(n)
+	return err
+}
+
+//Remove 从没有发出的牌中去掉死牌(已经发出的不处理,去掉的牌之后Reset也不会再出现),返回去掉的数量
+func (c *Poker) Remove(dead ...*Card) int {
+	mp := make(map[int8]bool)
+	for _, card := range dead {
+		mp[card.Value()] = true
+	}
+	removed := make(map[int8]bool)
+	left := c.cards[c.currentIndex:c.currentIndex]
+	for _, card := range c.cards[c.currentIndex:] {
+		if mp[card.Value()] {
+			removed[card.Value()] = true
+			continue
+		}
+		left = append(left, card)
+	}
+	c.cards = c.cards[:c.currentIndex+len(left)]
+	c.maxCards = len(c.cards)
+	deck := make([]*Card, 0, len(c.cards))
+	for _, card := range c.deck {
+		if !removed[card.Value()] {
+			deck = append(deck, card)
+		}
+	}
+	c.deck = deck
+	return len(removed)
+}
+
+//DeckSnapshot 牌堆的状态(整副牌的顺序和已经发到的位置)
+type DeckSnapshot struct {
+	Cards []*Card
+	Index int
+}
+
+//Snapshot 保存当前牌堆的状态(可以序列化后用RestorePoker恢复)
+func (c *Poker) Snapshot() *DeckSnapshot {
+	return &DeckSnapshot{
+		Cards: append(make([]*Card, 0, len(c.cards)), c.cards...),
+		Index: c.currentIndex,
+	}
+}
+
+//RestorePoker 从保存的状态恢复牌堆(牌不能重复,Reset时用这些牌重新洗牌)
+func RestorePoker(s *DeckSnapshot) (*Poker, error) {
+	if s == nil || s.Index < 0 || s.Index > len(s.Cards) {
+		return nil, ErrInvalidDeck
+	}
+	all := make(map[int8]*Card)
+	for _, card := range pokerCards {
+		all[card.Value()] = card
+	}
+	used := make(map[int8]bool)
+	cards := make([]*Card, 0, len(s.Cards))
+	for _, card := range s.Cards {
+		if card == nil {
+			return nil, ErrInvalidDeck
+		}
+		v := card.Value()
+		cd, ok := all[v]
+		if !ok || used[v] || cd.Num != card.Num || cd.Suit != card.Suit {
+			return nil, ErrInvalidDeck
+		}
+		used[v] = true
+		cards = append(cards, cd)
+	}
+	deck := make([]*Card, 0, len(cards))
+	for _, card := range pokerCards {
+		if used[card.Value()] {
+			deck = append(deck, card)
+		}
+	}
+	return &Poker{
+		cards:        cards,
+		currentIndex: s.Index,
+		maxCards:     len(cards),
+		deck:         deck,
+		source:       &cryptoSource{},
+	}, nil
+}
+
 //State 当前排序，最大牌数
 func (c *Poker) State() (int, int) {
 	return c.currentIndex, len(c.cards)
@@ -183,25 +315,79 @@ func GetOuts(allHands map[int8]*HandValue, allNextHands map[int8]map[*Card]*Hand
 
 //GetAllOuts 获取每一张补牌对应的最大手牌(当前最大手牌,和每发一张牌的最大手牌)
 func GetAllOuts(publicCards []*Card, seatCards map[int8][]*Card) (map[int8]*HandValue, map[int8]map[*Card]*HandValue) {
+	return GetAllOutsFromDeck(pokerCards, publicCards, seatCards)
+}
+
+//GetAllOutsFromDeck 同GetAllOuts,从deck(例如短牌)中剩下的牌补牌,按规则计算牌型
+func GetAllOutsFromDeck(deck []*Card, publicCards []*Card, seatCards map[int8][]*Card, rk ...*HandRanking) (map[int8]*HandValue, map[int8]map[*Card]*HandValue) {
+	return allOuts(deck, publicCards, seatCards, func(hole []*Card, board []*Card) (*HandValue, error) {
+		cards := make([]*Card, 0, len(board)+len(hole))
+		cards = append(cards, board...)
+		cards = append(cards, hole...)
+		return GetMaxHandValueFromCard(cards, rk...)
+	})
+}
+
+//GetOutsFromDeck 同GetOuts(GetAllOutsFromDeck(...)),用EvaluateBits比较牌型大小,只给outs中的牌生成HandValue
+func GetOutsFromDeck(deck []*Card, publicCards []*Card, seatCards map[int8][]*Card, pots []*Pot, rk ...*HandRanking) map[int8]*LeaderOuts {
+	var ranking *HandRanking
+	if len(rk) > 0 {
+		ranking = rk[0]
+	}
+	return fastOuts(deck, publicCards, seatCards, pots, func(hole []*Card, board []*Card) (int64, HandValueType) {
+		return bitsValue(hole, board, ranking)
+	}, func(hole []*Card, board []*Card) (*HandValue, error) {
+		cards := make([]*Card, 0, len(board)+len(hole))
+		cards = append(cards, board...)
+		cards = append(cards, hole...)
+		return GetMaxHandValueFromCard(cards, rk...)
+	})
+}
+
+//fastOuts 用value(手牌,公共牌)比较牌型大小计算outs(比较用的HandValue只有值和牌型),只给outs中的牌用eval生成完整的HandValue(可以TaggingCards)
+func fastOuts(deck []*Card, publicCards []*Card, seatCards map[int8][]*Card, pots []*Pot, value func(hole []*Card, board []*Card) (int64, HandValueType), eval func(hole []*Card, board []*Card) (*HandValue, error)) map[int8]*LeaderOuts {
+	current, next := allOuts(deck, publicCards, seatCards, func(hole []*Card, board []*Card) (*HandValue, error) {
+		v, tp := value(hole, board)
+		return &HandValue{value: v, maxHandValueType: tp}, nil
+	})
+	leaderOuts := GetOuts(current, next, pots)
+	board := make([]*Card, len(publicCards)+1)
+	copy(board, publicCards)
+	for _, lo := range leaderOuts {
+		for _, outs := range lo.Outs {
+			for seat, hvs := range outs.Detail {
+				for cd := range hvs {
+					board[len(publicCards)] = cd
+					hvs[cd], _ = eval(seatCards[seat], board)
+				}
+			}
+		}
+	}
+	return leaderOuts
+}
+
+//allOuts 用eval(手牌,公共牌)计算当前和每补一张牌的最大手牌(奥马哈等玩法)
+func allOuts(deck []*Card, publicCards []*Card, seatCards map[int8][]*Card, eval func(hole []*Card, board []*Card) (*HandValue, error)) (map[int8]*HandValue, map[int8]map[*Card]*HandValue) {
 	eCards := append(make([]*Card, 0), publicCards...)
 	mp := make(map[int8]*HandValue)
 	for s, v := range seatCards {
 		eCards = append(eCards, v...)
-		hv, _ := GetMaxHandValueFromCard(append(publicCards, v...))
+		hv, _ := eval(v, publicCards)
 		mp[s] = hv
 	}
-	poker := newPokerWithExceptCardsAndNoShuffle(eCards)
+	poker := newPokerWithExceptCardsAndNoShuffle(deck, eCards)
 	pcs := make(map[int8]map[*Card]*HandValue)
+	board := make([]*Card, len(publicCards)+1)
+	copy(board, publicCards)
 	for {
 		cards, err := poker.GetCards(1)
 		if err != nil {
 			break
 		}
 		card := cards[0]
+		board[len(publicCards)] = card
 		for seat, v := range seatCards {
-			cds := append(publicCards, v...)
-			cds = append(cds, card)
-			ohv, _ := GetMaxHandValueFromCard(cds)
+			ohv, _ := eval(v, board)
 			cardsMap, ok := pcs[seat]
 			if !ok {
 				cardsMap = make(map[*Card]*HandValue)
@@ -213,10 +399,10 @@ func GetAllOuts(publicCards []*Card, seatCards map[int8][]*Card) (map[int8]*Hand
 	return mp, pcs
 }
 
-func GetHandValueFromCard(nc []*Card) ([]*HandValue, error) {
+func GetHandValueFromCard(nc []*Card, rk ...*HandRanking) ([]*HandValue, error) {
 	switch len(nc) {
 	case 5:
-		nh, err := NewHandValue(nc)
+		nh, err := NewHandValue(nc, rk...)
 		if err != nil {
 			return nil, err
 		}
@@ -228,7 +414,7 @@ func GetHandValueFromCard(nc []*Card) ([]*HandValue, error) {
 			for i := 0; i < 5; i++ {
 				nnc = append(nnc, nc[out[i]])
 			}
-			hand, err := NewHandValue(nnc)
+			hand, err := NewHandValue(nnc, rk...)
 			if err != nil {
 				return err
 			}
@@ -246,7 +432,7 @@ func GetHandValueFromCard(nc []*Card) ([]*HandValue, error) {
 			for i := 0; i < 5; i++ {
 				nnc = append(nnc, nc[out[i]])
 			}
-			hand, err := NewHandValue(nnc)
+			hand, err := NewHandValue(nnc, rk...)
 			if err != nil {
 				return err
 			}
@@ -261,14 +447,39 @@ func GetHandValueFromCard(nc []*Card) ([]*HandValue, error) {
 	return nil, ErrInvalidCardLength
 }
 
-func GetMaxHandValueFromCard(nc []*Card) (*HandValue, error) {
-	hands, err := GetHandValueFromCard(nc)
+func GetMaxHandValueFromCard(nc []*Card, rk ...*HandRanking) (*HandValue, error) {
+	hands, err := GetHandValueFromCard(nc, rk...)
 	if err != nil {
 		return nil, err
 	}
 	return GetMaxHandValue(hands...)[0], nil
 }
 
+//GetMaxOmahaHandValueFromCard 奥马哈最大牌型(必须使用2张手牌+3张公共牌)
+func GetMaxOmahaHandValueFromCard(hole []*Card, board []*Card, rk ...*HandRanking) (*HandValue, error) {
+	if len(hole) < 2 || len(board) < 3 {
+		return nil, ErrInvalidCardLength
+	}
+	var max *HandValue
+	err := comb(len(hole), 2, func(ho []int) error {
+		return comb(len(board), 3, func(bo []int) error {
+			nnc := []*Card{hole[ho[0]], hole[ho[1]], board[bo[0]], board[bo[1]], board[bo[2]]}
+			hand, err := NewHandValue(nnc, rk...)
+			if err != nil {
+				return err
+			}
+			if max == nil || hand.value > max.value {
+				max = hand
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return max, nil
+}
+
 //GetMaxHandValue 通过标记获得最大的标记牌型
 func GetMaxHandValueFromTaggedHandValues(hvs map[int8]*HandValue) map[int8]*HandValue {
 	var maxValue int64
@@ -1,9 +1,11 @@
 package holdem
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -262,6 +264,269 @@ func TestHVRoyalFlush(t *testing.T) {
 	assert.Equal(r2.Value() > r1.Value(), true)
 }
 
+func TestOmahaHandValue(t *testing.T) {
+	assert := assert.New(t)
+	board := make([]*Card, 5)
+	board[0], _ = NewCard(2, 1)
+	board[1], _ = NewCard(7, 1)
+	board[2], _ = NewCard(9, 1)
+	board[3], _ = NewCard(13, 1)
+	board[4], _ = NewCard(13, 2)
+	hole := make([]*Card, 4)
+	hole[0], _ = NewCard(14, 1)
+	hole[1], _ = NewCard(3, 0)
+	hole[2], _ = NewCard(4, 3)
+	hole[3], _ = NewCard(5, 2)
+	//德州同花,奥马哈必须使用两张手牌只能是一对
+	r1, _ := GetMaxHandValueFromCard(append(append([]*Card{}, board...), hole[0], hole[1]))
+	assert.Equal(r1.MaxHandValueType(), HVFlush)
+	r2, err := GetMaxOmahaHandValueFromCard(hole, board)
+	assert.Nil(err)
+	assert.Equal(r2.MaxHandValueType(), HVOnePair)
+
+	hole[1], _ = NewCard(10, 1)
+	r3, _ := GetMaxOmahaHandValueFromCard(hole, board)
+	assert.Equal(r3.MaxHandValueType(), HVFlush)
+	assert.Equal(r3.HasCards(hole[0], hole[1]), true)
+
+	_, err = GetMaxOmahaHandValueFromCard(hole[:1], board)
+	assert.Equal(err, ErrInvalidCardLength)
+}
+
+func TestShortDeck(t *testing.T) {
+	assert := assert.New(t)
+	p := NewShortDeckPoker()
+	_, l := p.State()
+	assert.Equal(l, 36)
+	cs, _ := p.GetCards(36)
+	for _, c := range cs {
+		assert.Equal(c.Num >= 6, true)
+	}
+
+	c1, _ := NewCard(14, 2)
+	c2, _ := NewCard(6, 1)
+	c3, _ := NewCard(7, 0)
+	c4, _ := NewCard(8, 1)
+	c5, _ := NewCard(9, 3)
+	r1, _ := GetMaxHandValueFromCard([]*Card{c1, c2, c3, c4, c5})
+	assert.Equal(r1.MaxHandValueType(), HVHighCard)
+	r1, _ = GetMaxHandValueFromCard([]*Card{c1, c2, c3, c4, c5}, ShortDeckHandRanking)
+	assert.Equal(r1.MaxHandValueType(), HVStraight)
+	c6, _ := NewCard(10, 3)
+	r2, _ := GetMaxHandValueFromCard([]*Card{c2, c3, c4, c5, c6}, ShortDeckHandRanking)
+	assert.Equal(r2.Value() > r1.Value(), true)
+
+	f1, _ := NewCard(6, 1)
+	f2, _ := NewCard(8, 1)
+	f3, _ := NewCard(10, 1)
+	f4, _ := NewCard(12, 1)
+	f5, _ := NewCard(13, 1)
+	h1, _ := NewCard(14, 0)
+	h2, _ := NewCard(14, 2)
+	h3, _ := NewCard(14, 3)
+	h4, _ := NewCard(13, 0)
+	h5, _ := NewCard(13, 2)
+	flush, _ := GetMaxHandValueFromCard([]*Card{f1, f2, f3, f4, f5}, ShortDeckHandRanking)
+	fullHouse, _ := GetMaxHandValueFromCard([]*Card{h1, h2, h3, h4, h5}, ShortDeckHandRanking)
+	assert.Equal(flush.Value() > fullHouse.Value(), true)
+	flush, _ = GetMaxHandValueFromCard([]*Card{f1, f2, f3, f4, f5})
+	fullHouse, _ = GetMaxHandValueFromCard([]*Card{h1, h2, h3, h4, h5})
+	assert.Equal(flush.Value() < fullHouse.Value(), true)
+
+	rk := &HandRanking{ShortDeck: true, TripsBeatStraight: true}
+	trips, _ := GetMaxHandValueFromCard([]*Card{h1, h2, h3, f1, f2}, rk)
+	straight, _ := GetMaxHandValueFromCard([]*Card{c2, c3, c4, c5, c6}, rk)
+	assert.Equal(trips.Value() > straight.Value(), true)
+}
+
+func TestDeckSource(t *testing.T) {
+	assert := assert.New(t)
+	a := NewPoker()
+	a.source = NewSeededDeckSource(7)
+	a.Reset()
+	ca, _ := a.GetCards(52)
+	b := NewPoker()
+	b.source = NewSeededDeckSource(7)
+	b.Reset()
+	cb, _ := b.GetCards(52)
+	for i := range ca {
+		assert.Equal(ca[i].Value(), cb[i].Value())
+	}
+
+	h1, _ := NewCard(14, 0)
+	h2, _ := NewCard(14, 1)
+	f1, _ := NewCard(2, 3)
+	r1, _ := NewCard(9, 2)
+	p := NewPoker()
+	p.source = NewFixedDeckSource([]*Card{h1, h2, f1}, []*Card{r1})
+	p.Reset()
+	cs, _ := p.GetCards(3)
+	assert.Equal(cs[0].Value(), h1.Value())
+	assert.Equal(cs[1].Value(), h2.Value())
+	assert.Equal(cs[2].Value(), f1.Value())
+	//多次发牌时重洗剩余的牌用下一组
+	p.ResetAfterOffset(2)
+	cs, _ = p.GetCards(1)
+	assert.Equal(cs[0].Value(), r1.Value())
+	seen := make(map[int8]bool)
+	p.Reset()
+	cs, _ = p.GetCards(52)
+	for _, c := range cs {
+		seen[c.Value()] = true
+	}
+	assert.Equal(len(seen), 52)
+}
+
+func TestPokerDeckAPI(t *testing.T) {
+	assert := assert.New(t)
+	h1, _ := NewCard(14, 0)
+	h2, _ := NewCard(14, 1)
+	p := NewPokerWithoutCards(h1, h2)
+	_, l := p.State()
+	assert.Equal(l, 50)
+	cs, _ := p.Peek(3)
+	idx, _ := p.State()
+	assert.Equal(idx, 0)
+	assert.Equal(p.Burn(1), nil)
+	next, _ := p.GetCards(2)
+	assert.Equal(next[0], cs[1])
+	assert.Equal(next[1], cs[2])
+
+	//保存后恢复,接下来的牌一样
+	data, err := json.Marshal(p.Snapshot())
+	assert.Equal(err, nil)
+	var snap DeckSnapshot
+	assert.Equal(json.Unmarshal(data, &snap), nil)
+	q, err := RestorePoker(&snap)
+	assert.Equal(err, nil)
+	a, _ := p.GetCards(47)
+	b, _ := q.GetCards(47)
+	assert.Equal(a, b)
+	_, err = q.GetCards(1)
+	assert.Equal(err, ErrCardOutOfIndex)
+	assert.Equal(q.Burn(1), ErrCardOutOfIndex)
+
+	//去掉死牌(已经发出的不去掉)
+	p = NewPoker()
+	_ = p.Burn(10)
+	dealt, _ := p.peek(-10, 1)
+	d := *dealt[0]
+	left, _ := p.Peek(1)
+	assert.Equal(p.Remove(&d, left[0]), 1)
+	_, l = p.State()
+	assert.Equal(l, 51)
+	p.Reset()
+	all, _ := p.GetCards(51)
+	found := false
+	for _, c := range all {
+		assert.NotEqual(c.Value(), left[0].Value())
+		found = found || c.Value() == d.Value()
+	}
+	assert.Equal(found, true)
+
+	snap.Cards = append(snap.Cards, snap.Cards[0])
+	_, err = RestorePoker(&snap)
+	assert.Equal(err, ErrInvalidDeck)
+}
+
+func TestShuffleEntropy(t *testing.T) {
+	assert := assert.New(t)
+	p := NewPoker()
+	p.Reset()
+	entropy := p.source.(EntropySource).Entropy()
+	assert.Equal(len(entropy) >= 4*51, true)
+	cs, err := ReplayShuffle(p.deck, entropy)
+	assert.Equal(err, nil)
+	for i := range cs {
+		assert.Equal(cs[i].Value(), p.cards[i].Value())
+	}
+	_, err = ReplayShuffle(p.deck, entropy[:10])
+	assert.NotEqual(err, nil)
+}
+
+func TestVerifyFairHand(t *testing.T) {
+	assert := assert.New(t)
+	seed := []byte("server seed")
+	deck := fairDeck(pokerCards, seed, "u1=abc", 3)
+	commit := &FairCommitment{HandNum: 3, Deck: pokerCards, Hash: fairHash(seed, pokerCards)}
+	reveal := &FairReveal{HandNum: 3, ServerSeed: "7365727665722073656564", ClientSeed: "u1=abc", Deck: deck}
+	assert.Equal(VerifyFairHand(commit, reveal), nil)
+	//承诺的是短牌
+	short := &FairCommitment{HandNum: 3, Deck: shortPokerCards, Hash: fairHash(seed, shortPokerCards)}
+	assert.Equal(VerifyFairHand(short, reveal), ErrFairDeckMismatch)
+	reveal.Deck = fairDeck(shortPokerCards, seed, "u1=abc", 3)
+	assert.Equal(VerifyFairHand(short, reveal), nil)
+	reveal.Deck = deck
+
+	reveal.ServerSeed = "7365727665722073656565"
+	assert.Equal(VerifyFairHand(commit, reveal), ErrFairHashMismatch)
+	reveal.ServerSeed = "7365727665722073656564"
+	reveal.ClientSeed = "u1=abd"
+	assert.Equal(VerifyFairHand(commit, reveal), ErrFairDeckMismatch)
+	reveal.ClientSeed = "u1=abc"
+	swapped := append(make([]*Card, 0, len(deck)), deck...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	reveal.Deck = swapped
+	assert.Equal(VerifyFairHand(commit, reveal), ErrFairDeckMismatch)
+}
+
+//testFairReciever 记录可验证公平的承诺和公开
+type testFairReciever struct {
+	NopReciever
+	commits []*FairCommitment
+	reveals []*FairReveal
+	errs    []int
+}
+
+func (c *testFairReciever) RoomerGetFairCommitment(hid string, commit *FairCommitment) {
+	c.commits = append(c.commits, commit)
+}
+
+func (c *testFairReciever) RoomerGetFairReveal(hid string, reveal *FairReveal) {
+	c.reveals = append(c.reveals, reveal)
+}
+
+func (c *testFairReciever) ErrorOccur(hid string, code int, err error) {
+	c.errs = append(c.errs, code)
+}
+
+func TestFairCommitBeforeClientSeed(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHoldem("t", 6, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop(), OptionProvablyFair(), OptionShortDeck())
+	recv := &testFairReciever{}
+	r := NewAgent(recv, "a", zap.NewNop())
+	//加入时收到第一手的承诺
+	r.Join(h)
+	r.BringIn(500)
+	r.Seated(1)
+	if !assert.Equal(1, len(recv.commits)) {
+		return
+	}
+	assert.Equal(uint(1), recv.commits[0].HandNum)
+	assert.Equal(len(shortPokerCards), len(recv.commits[0].Deck))
+	r.ClientSeed("abc")
+	h.handNum = 1
+	h.fairShuffle()
+	//发牌后到公布下一手的承诺之前不接受种子
+	r.ClientSeed("late")
+	assert.Equal([]int{ErrCodeFairNoCommitment}, recv.errs)
+	h.revealFair()
+	if !assert.Equal(1, len(recv.reveals)) || !assert.Equal(2, len(recv.commits)) {
+		return
+	}
+	assert.Equal("a=abc", recv.reveals[0].ClientSeed)
+	assert.Nil(VerifyFairHand(recv.commits[0], recv.reveals[0]))
+	assert.Equal(recv.reveals[0].Deck[0], h.poker.cards[0])
+	assert.Equal(uint(2), recv.commits[1].HandNum)
+	//上一手的种子不会用到下一手
+	h.handNum = 2
+	h.fairShuffle()
+	h.revealFair()
+	assert.Equal("", recv.reveals[1].ClientSeed)
+	assert.Nil(VerifyFairHand(recv.commits[1], recv.reveals[1]))
+	assert.Equal(ErrFairHashMismatch, VerifyFairHand(recv.commits[0], recv.reveals[1]))
+}
+
 func TestCalcPots(t *testing.T) {
 	h := &Holdem{}
 	urs := make([]*Agent, 6)
@@ -320,6 +585,160 @@ func TestCalcPots(t *testing.T) {
 	}
 }
 
+//testRing 按座位顺序围成一圈的玩家(座位号从1开始,第一个为庄位)
+func testRing(bets []uint, status []ActionDef) []*Agent {
+	urs := make([]*Agent, len(bets))
+	for i := range bets {
+		urs[i] = &Agent{
+			gameInfo: &gameInfo{
+				seatNumber: int8(i + 1),
+				handBet:    bets[i],
+				status:     status[i],
+			},
+		}
+	}
+	for i := 0; i < len(urs)-1; i++ {
+		urs[i].nextAgent = urs[i+1]
+		urs[i+1].prevAgent = urs[i]
+	}
+	urs[0].prevAgent = urs[len(urs)-1]
+	urs[len(urs)-1].nextAgent = urs[0]
+	return urs
+}
+
+func TestCalcPotLevels(t *testing.T) {
+	assert := assert.New(t)
+	type pot struct {
+		num   uint
+		seats []int8
+	}
+	cases := []struct {
+		name   string
+		bets   []uint
+		status []ActionDef
+		pots   []pot
+	}{
+		{
+			name:   "all in",
+			bets:   []uint{1000, 2000, 4000, 4000, 5000, 7000},
+			status: []ActionDef{ActionDefAllIn, ActionDefAllIn, ActionDefAllIn, ActionDefAllIn, ActionDefAllIn, ActionDefAllIn},
+			pots:   []pot{{6000, []int8{1, 2, 3, 4, 5, 6}}, {5000, []int8{2, 3, 4, 5, 6}}, {8000, []int8{3, 4, 5, 6}}, {2000, []int8{5, 6}}, {2000, []int8{6}}},
+		},
+		{
+			name:   "no all in",
+			bets:   []uint{100, 100, 100},
+			status: []ActionDef{ActionDefCall, ActionDefCall, ActionDefCheck},
+			pots:   []pot{{300, []int8{1, 2, 3}}},
+		},
+		{
+			name:   "fold into side pot",
+			bets:   []uint{500, 300, 500},
+			status: []ActionDef{ActionDefFold, ActionDefAllIn, ActionDefCall},
+			pots:   []pot{{900, []int8{2, 3}}, {400, []int8{3}}},
+		},
+		{
+			name:   "fold over all in",
+			bets:   []uint{1000, 200, 200},
+			status: []ActionDef{ActionDefFold, ActionDefAllIn, ActionDefAllIn},
+			pots:   []pot{{1400, []int8{2, 3}}},
+		},
+	}
+	for _, cs := range cases {
+		all := testRing(cs.bets, cs.status)
+		urs := make([]*Agent, 0)
+		for _, u := range all {
+			if u.gameInfo.status != ActionDefFold {
+				urs = append(urs, u)
+			}
+		}
+		h := &Holdem{button: all[0]}
+		pots := h.calcPot(urs)
+		if !assert.Equal(len(cs.pots), len(pots), cs.name) {
+			continue
+		}
+		for i, p := range cs.pots {
+			assert.Equal(p.num, pots[i].Num, cs.name)
+			assert.Equal(len(p.seats), len(pots[i].SeatNumber), cs.name)
+			for _, seat := range p.seats {
+				assert.Equal(true, pots[i].SeatNumber[seat], cs.name)
+			}
+		}
+	}
+}
+
+//testCards 按"点数,花色"创建牌
+func testCards(cs ...[2]int8) []*Card {
+	ret := make([]*Card, 0, len(cs))
+	for _, c := range cs {
+		card, _ := NewCard(c[0], c[1])
+		ret = append(ret, card)
+	}
+	return ret
+}
+
+func TestCalcWinOddChip(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		button int
+		pot    uint
+		wins   map[int8]uint
+	}{
+		{button: 0, pot: 301, wins: map[int8]uint{2: 151, 3: 150}},
+		{button: 1, pot: 301, wins: map[int8]uint{2: 150, 3: 151}},
+		{button: 2, pot: 302, wins: map[int8]uint{2: 151, 3: 151}},
+	}
+	for _, cs := range cases {
+		urs := testRing([]uint{100, 100, 100}, []ActionDef{ActionDefCall, ActionDefCall, ActionDefCheck})
+		//2号和3号都是10到A的顺子
+		urs[0].gameInfo.cards = testCards([2]int8{2, 1}, [2]int8{3, 2})
+		urs[1].gameInfo.cards = testCards([2]int8{10, 1}, [2]int8{2, 2})
+		urs[2].gameInfo.cards = testCards([2]int8{10, 2}, [2]int8{3, 3})
+		h := &Holdem{
+			button:      urs[cs.button],
+			options:     &extOptions{},
+			publicCards: testCards([2]int8{14, 0}, [2]int8{13, 1}, [2]int8{12, 2}, [2]int8{11, 3}, [2]int8{9, 0}),
+		}
+		pots := []*Pot{{Num: cs.pot, SeatNumber: map[int8]bool{1: true, 2: true, 3: true}}}
+		results, _, _ := h.calcWin(urs, pots, h.publicCards)
+		assert.Equal(len(cs.wins), len(results))
+		for seat, num := range cs.wins {
+			assert.Equal(num, results[seat].Num, "button %d", cs.button)
+		}
+	}
+}
+
+func TestRunMultipleSplitPots(t *testing.T) {
+	assert := assert.New(t)
+	//1号:AA全下100 2号:KK全下300 3号:QQ跟注300,主池300(1,2,3) 边池400(2,3)
+	boards := [][]*Card{
+		testCards([2]int8{2, 0}, [2]int8{7, 2}, [2]int8{9, 3}, [2]int8{4, 0}, [2]int8{11, 2}),
+		testCards([2]int8{12, 0}, [2]int8{2, 1}, [2]int8{7, 3}, [2]int8{8, 2}, [2]int8{3, 0}),
+		testCards([2]int8{13, 0}, [2]int8{5, 1}, [2]int8{6, 3}, [2]int8{10, 2}, [2]int8{2, 2}),
+	}
+	cases := []struct {
+		runs int
+		wins map[int8]uint
+	}{
+		{runs: 1, wins: map[int8]uint{1: 300, 2: 400, 3: 0}},
+		{runs: 2, wins: map[int8]uint{1: 150, 2: 200, 3: 350}},
+		//零头给第一次
+		{runs: 3, wins: map[int8]uint{1: 100, 2: 367, 3: 233}},
+	}
+	for _, cs := range cases {
+		urs := testRing([]uint{100, 300, 300}, []ActionDef{ActionDefAllIn, ActionDefAllIn, ActionDefCall})
+		urs[0].gameInfo.cards = testCards([2]int8{14, 1}, [2]int8{14, 3})
+		urs[1].gameInfo.cards = testCards([2]int8{13, 1}, [2]int8{13, 3})
+		urs[2].gameInfo.cards = testCards([2]int8{12, 1}, [2]int8{12, 3})
+		h := NewHoldem("t", 3, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop())
+		h.button = urs[0]
+		h.pot = 700
+		h.complexWin(urs, boards[:cs.runs]...)
+		for seat, num := range cs.wins {
+			assert.Equal(num, urs[seat-1].gameInfo.chip, "runs %d", cs.runs)
+		}
+	}
+}
+
 func TestGetOuts(t *testing.T) {
 	publicCards := make([]*Card, 4)
 	publicCards[0], _ = NewCard(12, 0)
@@ -378,6 +797,359 @@ type TestAd2 struct {
 	Ad *TestAd
 }
 
+func TestEvaluateBits(t *testing.T) {
+	assert := assert.New(t)
+	check := func(p *Poker, n int, rk *HandRanking) {
+		p.Reset()
+		cs, _ := p.GetCards(n)
+		hv, _ := GetMaxHandValueFromCard(cs, rk)
+		v, tp, err := FastHandValue(cs, rk)
+		assert.Equal(err, nil)
+		if !assert.Equal(v, hv.Value()) || !assert.Equal(tp, hv.MaxHandValueType()) {
+			t.Log(cs, hv)
+		}
+	}
+	trips := &HandRanking{TripsBeatStraight: true}
+	for i := 0; i < 20000; i++ {
+		n := 5 + i%3
+		check(NewPoker(), n, nil)
+		check(NewPoker(), n, trips)
+		check(NewShortDeckPoker(), n, ShortDeckHandRanking)
+	}
+	c1, _ := NewCard(14, 0)
+	c2, _ := NewCard(2, 1)
+	c3, _ := NewCard(3, 2)
+	c4, _ := NewCard(4, 3)
+	c5, _ := NewCard(5, 0)
+	v, tp, _ := FastHandValue([]*Card{c1, c2, c3, c4, c5})
+	assert.Equal(tp, HVStraight)
+	assert.Equal(v, int64(HVStraight)<<20+5)
+	_, _, err := FastHandValue([]*Card{c1, c2, c3, c4})
+	assert.Equal(err, ErrInvalidCardLength)
+	assert.Equal(c4.Bits().Num(), int8(4))
+	assert.Equal(c4.Bits().Suit(), int8(3))
+}
+
+func BenchmarkGetMaxHandValueFromCard(b *testing.B) {
+	p := NewPoker()
+	cs, _ := p.GetCards(7)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = GetMaxHandValueFromCard(cs)
+	}
+}
+
+func BenchmarkEvaluateBits(b *testing.B) {
+	p := NewPoker()
+	cs, _ := p.GetCards(7)
+	cb := make([]CardBits, 0, 7)
+	for _, c := range cs {
+		cb = append(cb, c.Bits())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = EvaluateBits(cb)
+	}
+}
+
+func TestGetAllOutsShortDeck(t *testing.T) {
+	assert := assert.New(t)
+	board := testCards([2]int8{6, 0}, [2]int8{7, 1}, [2]int8{8, 2}, [2]int8{11, 3})
+	mp := map[int8][]*Card{
+		1: testCards([2]int8{14, 0}, [2]int8{14, 1}),
+		2: testCards([2]int8{9, 1}, [2]int8{13, 3}),
+	}
+	current, next := GetAllOutsFromDeck(shortPokerCards, board, mp, ShortDeckHandRanking)
+	assert.Equal(current[1].MaxHandValueType(), HVOnePair)
+	assert.Equal(len(next[2]), 36-4-4)
+	for cd, hv := range next[2] {
+		assert.Equal(cd.Num >= 6, true)
+		//短牌A-6-7-8-9是顺子
+		if cd.Num == 14 || cd.Num == 10 {
+			assert.Equal(hv.MaxHandValueType(), HVStraight, cd.String())
+		}
+	}
+	//标准规则下A不能连6
+	_, next = GetAllOutsFromDeck(shortPokerCards, board, mp)
+	for cd, hv := range next[2] {
+		if cd.Num == 14 {
+			assert.Equal(hv.MaxHandValueType(), HVHighCard, cd.String())
+		}
+	}
+}
+
+func TestAllOutsOmaha(t *testing.T) {
+	assert := assert.New(t)
+	board := testCards([2]int8{14, 0}, [2]int8{13, 0}, [2]int8{7, 1}, [2]int8{2, 3})
+	mp := map[int8][]*Card{
+		1: testCards([2]int8{12, 0}, [2]int8{11, 0}, [2]int8{3, 1}, [2]int8{4, 3}),
+		2: testCards([2]int8{14, 1}, [2]int8{14, 3}, [2]int8{14, 2}, [2]int8{5, 2}),
+	}
+	h := &Holdem{options: &extOptions{omaha: true}}
+	current, next := allOuts(pokerCards, board, mp, h.maxHandValue)
+	//只能用2张手牌,三张A只算三条
+	assert.Equal(current[2].MaxHandValueType(), HVThreeOfAKind)
+	assert.Equal(len(next[1]), 52-4-8)
+	for cd, hv := range next[1] {
+		if cd.Num == 10 && cd.Suit == 0 {
+			assert.Equal(hv.MaxHandValueType(), HVRoyalFlush)
+		}
+	}
+}
+
+func TestFastOuts(t *testing.T) {
+	assert := assert.New(t)
+	pots := func() []*Pot {
+		return []*Pot{{SeatNumber: map[int8]bool{1: true, 2: true, 3: true}, Num: 300}}
+	}
+	//和完整计算每个HandValue的结果一致
+	check := func(name string, full map[int8]*LeaderOuts, fast map[int8]*LeaderOuts) {
+		assert.Equal(len(full), len(fast), name)
+		for s, lo := range full {
+			if !assert.NotNil(fast[s], name) {
+				continue
+			}
+			for _, outs := range lo.Outs {
+				for _, fo := range fast[s].Outs {
+					assert.Equal(outs.Len, fo.Len, name)
+					assert.Equal(len(outs.Detail), len(fo.Detail), name)
+					for seat, hvs := range outs.Detail {
+						assert.Equal(len(hvs), len(fo.Detail[seat]), name)
+						for cd, hv := range hvs {
+							if fhv := fo.Detail[seat][cd]; assert.NotNil(fhv, name) {
+								assert.Equal(hv.Value(), fhv.Value(), name)
+								assert.Equal(hv.Cards(), fhv.Cards(), name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	cases := []struct {
+		name  string
+		deck  []*Card
+		hole  int
+		omaha bool
+		rk    *HandRanking
+	}{
+		{"holdem", pokerCards, 2, false, nil},
+		{"short deck", shortPokerCards, 2, false, ShortDeckHandRanking},
+		{"omaha", pokerCards, 4, true, nil},
+	}
+	for _, cs := range cases {
+		h := &Holdem{options: &extOptions{omaha: cs.omaha, ranking: cs.rk}}
+		for i := 0; i < 50; i++ {
+			p := newPoker(cs.deck)
+			mp := make(map[int8][]*Card)
+			for s := int8(1); s <= 3; s++ {
+				mp[s], _ = p.GetCards(cs.hole)
+			}
+			//翻牌和转牌
+			board, _ := p.GetCards(3 + i%2)
+			current, next := allOuts(cs.deck, board, mp, h.maxHandValue)
+			full := GetOuts(current, next, pots())
+			check(cs.name, full, fastOuts(cs.deck, board, mp, pots(), h.fastHandValue, h.maxHandValue))
+			if !cs.omaha {
+				check(cs.name, full, GetOutsFromDeck(cs.deck, board, mp, pots(), cs.rk))
+			}
+		}
+	}
+}
+
+func benchmarkOuts(b *testing.B, outs func(board []*Card, mp map[int8][]*Card, pots []*Pot)) {
+	board := testCards([2]int8{12, 0}, [2]int8{7, 1}, [2]int8{4, 1}, [2]int8{10, 0})
+	mp := map[int8][]*Card{
+		1: testCards([2]int8{13, 0}, [2]int8{12, 3}),
+		2: testCards([2]int8{5, 1}, [2]int8{6, 1}),
+		3: testCards([2]int8{13, 1}, [2]int8{11, 1}),
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		outs(board, mp, []*Pot{{SeatNumber: map[int8]bool{1: true, 2: true, 3: true}}})
+	}
+}
+
+func BenchmarkGetOuts(b *testing.B) {
+	benchmarkOuts(b, func(board []*Card, mp map[int8][]*Card, pots []*Pot) {
+		current, next := GetAllOuts(board, mp)
+		GetOuts(current, next, pots)
+	})
+}
+
+func BenchmarkGetOutsFromDeck(b *testing.B) {
+	benchmarkOuts(b, func(board []*Card, mp map[int8][]*Card, pots []*Pot) {
+		GetOutsFromDeck(pokerCards, board, mp, pots)
+	})
+}
+
+//testSettleReciever 记录结算
+type testSettleReciever struct {
+	NopReciever
+	settlements []*Settlement
+}
+
+func (c *testSettleReciever) PlayerSettlement(hid string, s *Settlement) {
+	c.settlements = append(c.settlements, s)
+}
+
+func TestStandUpPendingChip(t *testing.T) {
+	assert := assert.New(t)
+	for _, exchange := range []bool{false, true} {
+		h := NewHoldem("t", 6, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop())
+		recv := &testSettleReciever{}
+		r := NewAgent(recv, "a", zap.NewNop())
+		r.gameInfo = &gameInfo{seatNumber: 2, chip: 200, bringIn: 200}
+		h.transferChip(playerAccount(r.id), accountCashier, 200, "bring in")
+		h.players[2] = r
+		h.playerCount = 1
+		//手中带入等到下一手
+		h.statusChange(GameStatusHandPreflop)
+		h.bringIn(r, 100)
+		assert.Equal(uint(100), r.gameInfo.pendingChip)
+		assert.Equal(uint(200), r.gameInfo.chip)
+		if exchange {
+			r.gameInfo.transfer = &transfer{to: h}
+		}
+		h.standUp(2, r, StandUpAction)
+		assert.Equal(int64(0), h.ledger.balance(playerAccount("a")))
+		assert.Equal(int64(0), h.ledger.balance(accountCashier))
+		if exchange {
+			if assert.Equal(1, len(h.transfers)) {
+				assert.Equal(uint(300), h.transfers[0].chip)
+			}
+		}
+		if assert.Equal(1, len(recv.settlements)) {
+			assert.Equal(uint(300), recv.settlements[0].BringIn)
+			assert.Equal(uint(300), recv.settlements[0].Chip)
+			assert.Equal(exchange, recv.settlements[0].Transfer)
+		}
+	}
+}
+
+func TestTransferReserveSeat(t *testing.T) {
+	assert := assert.New(t)
+	h1 := NewHoldem("t1", 3, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop())
+	h2 := NewHoldem("t2", 3, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop())
+	sit := func(h *Holdem, id string, seat int8) *Agent {
+		r := NewAgent(&NopReciever{}, id, zap.NewNop())
+		h.join(r)
+		r.gameInfo = &gameInfo{chip: 500, bringIn: 500}
+		h.seated(seat, r)
+		return r
+	}
+	a := sit(h1, "a", 1)
+	sit(h1, "b", 2)
+	sit(h2, "c", 1)
+	sit(h2, "d", 2)
+	//不在本桌的玩家不能换桌,放掉预留的座位
+	assert.Equal(errPlayerNotAtTable, h1.Transfer("c", h2, 3))
+	assert.Equal(0, len(h2.reserved))
+	//座位被占时预留其他空位,预留的座位别人不能坐
+	assert.Nil(h1.Transfer("a", h2, 2))
+	assert.Equal("a", h2.reserved[3])
+	assert.Equal(0, len(h2.State().EmptySeats))
+	e := sit(h2, "e", 3)
+	assert.Equal(0, int(e.gameInfo.seatNumber))
+	//目标桌子没有空位时不离开本桌
+	assert.Equal(errTableIsFull, h1.Transfer("b", h2, 0))
+	assert.Nil(h1.players[2].gameInfo.transfer)
+	h1.sweepTransfers()
+	assert.Equal(1, int(h1.playerCount))
+	assert.Nil(h1.roomers["a"])
+	assert.Equal(a, h2.players[3])
+	assert.Equal(h2, a.h)
+	assert.Equal(uint(500), a.gameInfo.chip)
+	assert.Equal(0, len(h2.reserved))
+	assert.Equal(int64(500), h2.ledger.balance(playerAccount("a")))
+	assert.Equal(int64(0), h1.ledger.balance(playerAccount("a")))
+}
+
+func TestJackpotQualify(t *testing.T) {
+	assert := assert.New(t)
+	board := testCards([2]int8{14, 0}, [2]int8{14, 1}, [2]int8{14, 3}, [2]int8{8, 2}, [2]int8{2, 0})
+	cases := []struct {
+		name string
+		hole []*Card
+		min  HandValueType
+		both bool
+		ok   bool
+	}{
+		{"quads", testCards([2]int8{14, 2}, [2]int8{3, 3}), HVFourOfAKind, false, true},
+		//四条只用了一张手牌(踢脚是公共牌的8)
+		{"quads one hole card", testCards([2]int8{14, 2}, [2]int8{3, 3}), HVFourOfAKind, true, false},
+		{"quads hole kicker", testCards([2]int8{14, 2}, [2]int8{13, 0}), HVFourOfAKind, true, true},
+		{"full house both", testCards([2]int8{7, 1}, [2]int8{7, 3}), HVFullHouse, true, true},
+		{"full house not quads", testCards([2]int8{7, 1}, [2]int8{7, 3}), HVFourOfAKind, false, false},
+		//AAA22只用了一张手牌
+		{"full house one hole card", testCards([2]int8{2, 1}, [2]int8{3, 3}), HVFullHouse, true, false},
+	}
+	h := &Holdem{options: &extOptions{}}
+	for _, cs := range cases {
+		urs := testRing([]uint{100}, []ActionDef{ActionDefCall})
+		urs[0].gameInfo.cards = cs.hole
+		h.showDown(urs, board)
+		p := &JackpotPolicy{MinHand: cs.min, BothHoleCards: cs.both}
+		assert.Equal(cs.ok, p.qualify(urs[0]), cs.name)
+	}
+}
+
+func TestCheckJackpot(t *testing.T) {
+	assert := assert.New(t)
+	board := testCards([2]int8{9, 0}, [2]int8{10, 0}, [2]int8{11, 0}, [2]int8{9, 1}, [2]int8{9, 3})
+	cases := []struct {
+		name   string
+		others int
+		wins   map[int8]uint
+		left   uint
+	}{
+		//1号输家(四条)505,2号赢家(同花顺)252,3号和4号平分101,零头给庄位后第一个
+		{"table share", 2, map[int8]uint{1: 505, 2: 252, 3: 51, 4: 50}, 152},
+		//没有其他玩家时桌上的部分留在奖池
+		{"no others", 0, map[int8]uint{1: 505, 2: 252}, 253},
+	}
+	for _, cs := range cases {
+		j := NewJackpot(&JackpotPolicy{MinHand: HVFourOfAKind, LoserShare: 50, WinnerShare: 25, TableShare: 10}, 1010)
+		n := 2 + cs.others
+		urs := testRing(make([]uint, n), make([]ActionDef, n))
+		for i, u := range urs {
+			u.id = string(rune('a' + i))
+		}
+		urs[0].gameInfo.cards = testCards([2]int8{9, 2}, [2]int8{2, 2})
+		urs[1].gameInfo.cards = testCards([2]int8{12, 0}, [2]int8{13, 0})
+		if cs.others > 0 {
+			urs[2].gameInfo.cards = testCards([2]int8{2, 1}, [2]int8{3, 1})
+			urs[3].gameInfo.cards = testCards([2]int8{4, 3}, [2]int8{5, 3})
+		}
+		h := NewHoldem("t", 6, 10, time.Second, func(*HoldemState) bool { return false }, zap.NewNop(), OptionJackpot(j))
+		//庄位是2号,3号是庄位后第一个
+		h.button = urs[1]
+		h.showDown(urs[:2], board)
+		hit, wins := h.checkJackpot(urs[:2], map[int8]uint{2: 100})
+		if !assert.NotNil(hit, cs.name) {
+			continue
+		}
+		assert.Equal(HVFourOfAKind, hit.LoserHand, cs.name)
+		assert.Equal(HVStraightFlush, hit.WinnerHand, cs.name)
+		assert.Equal(cs.left, hit.Left, cs.name)
+		assert.Equal(cs.left, j.Amount(), cs.name)
+		assert.Equal(len(cs.wins), len(wins), cs.name)
+		for seat, num := range cs.wins {
+			assert.Equal(num, wins[seat], cs.name)
+			assert.Equal(num, urs[seat-1].gameInfo.chip, cs.name)
+		}
+		//高牌型促销
+		h.options.highHand = &HighHandPolicy{MinHand: HVFourOfAKind}
+		assert.Equal(2, len(h.checkHighHands(urs[:2])), cs.name)
+		h.options.highHand.BothHoleCards = true
+		hands := h.checkHighHands(urs[:2])
+		if assert.Equal(1, len(hands), cs.name) {
+			assert.Equal(int8(2), hands[0].SeatNumber, cs.name)
+			assert.Equal(HVStraightFlush, hands[0].HandValueType, cs.name)
+		}
+	}
+}
+
 func TestPointer(t *testing.T) {
 	a := &TestAd{
 		Num: 1,
@@ -0,0 +1,69 @@
+package holdem
+
+import "go.uber.org/zap"
+
+//keepRabbitCards 提前结束时保存剩余的公共牌(按发牌顺序,每条街先烧一张牌)
+func (c *Holdem) keepRabbitCards() {
+	if !c.options.rabbitHunt {
+		return
+	}
+	cards := make([]*Card, 0, 5)
+	offset := 0
+	for n := len(c.publicCards); n < 5; {
+		cnt := 1
+		if n == 0 {
+			cnt = 3
+		}
+		cds, err := c.poker.peek(offset+1, cnt)
+		if err != nil {
+			break
+		}
+		cards = append(cards, cds...)
+		offset += cnt + 1
+		//双公共牌第二组也要跳过
+		if c.doubleBoard() {
+			offset += cnt + 1
+		}
+		n += cnt
+	}
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	if len(cards) > 0 {
+		c.rabbitCards = cards
+	}
+}
+
+//clearRabbitCards 新的一手开始清理剩余的公共牌
+func (c *Holdem) clearRabbitCards() {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	c.rabbitCards = nil
+	c.rabbitHunted = false
+}
+
+//rabbitHunt 看剩余的公共牌(展示给所有人)
+func (c *Holdem) rabbitHunt(r *Agent) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	if !c.options.rabbitHunt || c.rabbitCards == nil || c.rabbitHunted {
+		r.recv.ErrorOccur(c.id, ErrCodeCannotRabbitHunt, errCannotRabbitHunt)
+		return
+	}
+	if c.options.rabbitHuntLimit > 0 && r.gameInfo.rabbitHuntTimes >= c.options.rabbitHuntLimit {
+		r.recv.ErrorOccur(c.id, ErrCodeRabbitHuntOverTimes, errRabbitHuntOverTimes)
+		return
+	}
+	if r.gameInfo.chip < c.options.rabbitHuntPrice {
+		r.recv.ErrorOccur(c.id, ErrCodeNoChip, errNoChip)
+		return
+	}
+	r.gameInfo.chip -= c.options.rabbitHuntPrice
+	c.transferChip(accountHouse, playerAccount(r.id), c.options.rabbitHuntPrice, "rabbit hunt")
+	r.gameInfo.rabbitHuntTimes++
+	c.rabbitHunted = true
+	c.options.recorder.RabbitHunt(c.base(), r.gameInfo.seatNumber, r.ID(), r.gameInfo.chip, c.options.rabbitHuntPrice, c.rabbitCards)
+	for _, rr := range c.roomers {
+		rr.recv.RoomerGetRabbitCards(c.id, r.gameInfo.seatNumber, r.ID(), c.rabbitCards)
+	}
+	c.log.Debug("rabbit hunt", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("user", r.ID()), zap.Uint("cost", c.options.rabbitHuntPrice))
+}
@@ -0,0 +1,150 @@
+package holdem
+
+import (
+	"math"
+
+	"go.uber.org/zap"
+)
+
+//RakePolicy 抽水规则(分配前从池中扣除)
+type RakePolicy struct {
+	//Rate 抽水比例(0.05为5%)
+	Rate float64
+	//Cap 每手封顶(0为不封顶)
+	Cap uint
+	//Caps 按发牌人数的封顶(用不超过发牌人数的最大一档,没有时用Cap)
+	Caps map[int8]uint
+	//NoFlopNoDrop 没有发翻牌不抽水
+	NoFlopNoDrop bool
+	//MainPotOnly 只抽主池(否则每个边池都抽)
+	MainPotOnly bool
+}
+
+//limit 按发牌人数的封顶(0为不封顶)
+func (c *RakePolicy) limit(players int8) uint {
+	var n int8
+	ret := c.Cap
+	for k, v := range c.Caps {
+		if k <= players && k > n {
+			n = k
+			ret = v
+		}
+	}
+	return ret
+}
+
+//take 从各个数额中抽水(超过封顶的部分不抽)
+func (c *RakePolicy) take(nums []uint, players int8) []uint {
+	ret := make([]uint, len(nums))
+	limit := c.limit(players)
+	var total uint
+	for i, num := range nums {
+		if c.MainPotOnly && i > 0 {
+			break
+		}
+		rake := uint(math.Floor(float64(num)*c.Rate + 1e-9))
+		if limit > 0 && total+rake > limit {
+			rake = limit - total
+		}
+		ret[i] = rake
+		total += rake
+	}
+	return ret
+}
+
+//takeRake 从池中扣除抽水(只有一个人参与的池是没有跟注的部分不抽),返回每个池扣除的数量
+func (c *Holdem) takeRake(pots []*Pot) []uint {
+	rakes := make([]uint, len(pots))
+	p := c.options.rakePolicy
+	if p == nil || (p.NoFlopNoDrop && len(c.publicCards) == 0) {
+		return rakes
+	}
+	nums := make([]uint, 0, len(pots))
+	idx := make([]int, 0, len(pots))
+	for i, pot := range pots {
+		if len(pot.SeatNumber) > 1 {
+			nums = append(nums, pot.Num)
+			idx = append(idx, i)
+		}
+	}
+	for i, rake := range p.take(nums, c.playingPlayerCount) {
+		j := idx[i]
+		pots[j].Num -= rake
+		rakes[j] = rake
+		c.rake += rake
+	}
+	if c.rake > 0 {
+		c.log.Debug("rake", zap.Uint("rake", c.rake), zap.Uints("pots", rakes))
+	}
+	return rakes
+}
+
+//rakeShares 每个池的抽水按赢得的份额算到赢家头上(零头算给庄位后第一个赢家)
+func (c *Holdem) rakeShares(rakes []uint, potWins []map[int8]uint) map[int8]uint {
+	ret := make(map[int8]uint)
+	for j, rake := range rakes {
+		if rake == 0 {
+			continue
+		}
+		var total uint
+		for _, num := range potWins[j] {
+			total += num
+		}
+		if total == 0 {
+			continue
+		}
+		var given uint
+		var first int8
+		w := c.button.nextAgent
+		for {
+			seat := w.gameInfo.seatNumber
+			if num, ok := potWins[j][seat]; ok && num > 0 {
+				share := rake * num / total
+				given += share
+				ret[seat] += share
+				if first == 0 {
+					first = seat
+				}
+			}
+			if w == c.button {
+				break
+			}
+			w = w.nextAgent
+		}
+		ret[first] += rake - given
+	}
+	return ret
+}
+
+//calledPot 只有一人未盖牌时池中有人跟注的部分
+func (c *Holdem) calledPot(agent *Agent) uint {
+	var called uint
+	u := c.button
+	for {
+		if u != agent && u.gameInfo.handBet > called {
+			called = u.gameInfo.handBet
+		}
+		u = u.nextAgent
+		if u == c.button {
+			break
+		}
+	}
+	num := c.pot
+	if bet := agent.gameInfo.handBet; bet > called {
+		num -= bet - called
+	}
+	return num
+}
+
+//simpleRake 只有一人未盖牌时的抽水(不抽没有跟注的部分)
+func (c *Holdem) simpleRake(num uint) uint {
+	p := c.options.rakePolicy
+	if p == nil || (p.NoFlopNoDrop && len(c.publicCards) == 0) {
+		return 0
+	}
+	c.rake = p.take([]uint{num}, c.playingPlayerCount)[0]
+	if c.rake > 0 {
+		c.log.Debug("rake", zap.Uint("rake", c.rake))
+	}
+	return c.rake
+}
@@ -0,0 +1,48 @@
+package holdem
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//departure 离桌时的筹码
+type departure struct {
+	chip uint
+	at   time.Time
+}
+
+//recordDeparture 记录站起时的筹码(换桌和没有筹码的不记录)(有锁)
+func (c *Holdem) recordDeparture(r *Agent) {
+	if c.options.ratholeWindow <= 0 || r.gameInfo.chip == 0 || r.gameInfo.transfer != nil {
+		return
+	}
+	c.departures[r.id] = &departure{
+		chip: r.gameInfo.chip,
+		at:   time.Now(),
+	}
+}
+
+//ratholeChip 在时间窗口内重新坐下需要带入的最少筹码(0为没有限制)(有锁)
+func (c *Holdem) ratholeChip(id string) uint {
+	d, ok := c.departures[id]
+	if !ok {
+		return 0
+	}
+	if time.Since(d.at) > c.options.ratholeWindow {
+		delete(c.departures, id)
+		return 0
+	}
+	return d.chip
+}
+
+//checkRathole 坐下时检查是否带入了离桌时的筹码,通过后清除记录(有锁)
+func (c *Holdem) checkRathole(r *Agent) *errorWithCode {
+	need := c.ratholeChip(r.id)
+	if r.gameInfo.chip < need {
+		c.log.Debug("user rathole", zap.String("id", r.id), zap.Uint("chip", r.gameInfo.chip), zap.Uint("need", need))
+		return &errorWithCode{ErrCodeBuyInRathole, errBuyInRathole}
+	}
+	delete(c.departures, r.id)
+	return nil
+}
@@ -0,0 +1,166 @@
+package holdem
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//RebuyPolicy 重购/加码/重新参赛规则
+type RebuyPolicy struct {
+	//Period 游戏开始后允许重购/重新参赛的时间(0为不限制)
+	Period time.Duration
+	//MaxRebuys 每次参赛最多重购次数(0为不能重购)
+	MaxRebuys uint
+	//Threshold 筹码不超过这个数才能重购(0为输光才能重购)
+	Threshold uint
+	//RebuyChip 每次重购的筹码
+	RebuyChip uint
+	//AddOnChip 加码的筹码(0为不能加码),每次参赛只能在休息时加一次
+	AddOnChip uint
+	//MaxReEntries 被淘汰后最多重新参赛次数(0为不能重新参赛)
+	MaxReEntries uint
+	//ReEntryChip 重新参赛的筹码
+	ReEntryChip uint
+	//Price 每次重购/加码/重新参赛加入奖池的金额(单桌赛)
+	Price uint
+}
+
+//rebuyState 重购/加码/重新参赛记录(按用户)
+type rebuyState struct {
+	policy     *RebuyPolicy
+	start      time.Time
+	rebuys     map[string]uint //本次参赛的重购次数
+	addOns     map[string]bool //本次参赛是否已经加码
+	entries    map[string]uint //重新参赛次数
+	eliminated map[string]bool //已经被淘汰
+}
+
+func newRebuyState(policy *RebuyPolicy) *rebuyState {
+	return &rebuyState{
+		policy:     policy,
+		rebuys:     make(map[string]uint),
+		addOns:     make(map[string]bool),
+		entries:    make(map[string]uint),
+		eliminated: make(map[string]bool),
+	}
+}
+
+//inPeriod 是否还在重购期内
+func (c *rebuyState) inPeriod() bool {
+	if c.policy.Period <= 0 {
+		return true
+	}
+	return !c.start.IsZero() && time.Since(c.start) < c.policy.Period
+}
+
+//canRebuy 输光的玩家是否还可以重购(有锁)
+func (c *Holdem) canRebuy(r *Agent) bool {
+	s := c.rebuy
+	return s != nil && s.policy.MaxRebuys > 0 && s.inPeriod() && s.rebuys[r.id] < s.policy.MaxRebuys
+}
+
+//addPrize 重购/加码/重新参赛加入奖池(有锁)
+func (c *Holdem) addPrize() {
+	if c.sng != nil {
+		c.sng.prizePool += c.rebuy.policy.Price
+	}
+}
+
+//rebuyChip 重购
+func (c *Holdem) rebuyChip(r *Agent) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	s := c.rebuy
+	if s == nil || s.policy.MaxRebuys == 0 {
+		r.recv.ErrorOccur(c.id, ErrCodeRebuyNotAllowed, errRebuyNotAllowed)
+		return
+	}
+	if !s.inPeriod() {
+		r.recv.ErrorOccur(c.id, ErrCodeRebuyClosed, errRebuyClosed)
+		return
+	}
+	if s.rebuys[r.id] >= s.policy.MaxRebuys {
+		r.recv.ErrorOccur(c.id, ErrCodeRebuyOverTimes, errRebuyOverTimes)
+		return
+	}
+	if r.gameInfo.chip+r.gameInfo.pendingChip > s.policy.Threshold {
+		r.recv.ErrorOccur(c.id, ErrCodeRebuyTooManyChips, errRebuyTooManyChips)
+		return
+	}
+	s.rebuys[r.id]++
+	r.gameInfo.pendingChip += s.policy.RebuyChip
+	c.addPrize()
+	c.log.Debug("user rebuy", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("user", r.id), zap.Uint("chip", s.policy.RebuyChip), zap.Uint("times", s.rebuys[r.id]))
+	r.recv.PlayerRebuySuccess(c.id, r.gameInfo.seatNumber, r.id, s.policy.RebuyChip, s.rebuys[r.id])
+}
+
+//addOn 加码(休息时一次)
+func (c *Holdem) addOn(r *Agent) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	s := c.rebuy
+	if s == nil || s.policy.AddOnChip == 0 || c.blinds == nil || !c.blinds.schedule[c.blinds.level].Break {
+		r.recv.ErrorOccur(c.id, ErrCodeAddOnNotAllowed, errAddOnNotAllowed)
+		return
+	}
+	if s.addOns[r.id] {
+		r.recv.ErrorOccur(c.id, ErrCodeAddOnOverTimes, errAddOnOverTimes)
+		return
+	}
+	s.addOns[r.id] = true
+	r.gameInfo.pendingChip += s.policy.AddOnChip
+	c.addPrize()
+	c.log.Debug("user add on", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("user", r.id), zap.Uint("chip", s.policy.AddOnChip))
+	r.recv.PlayerAddOnSuccess(c.id, r.gameInfo.seatNumber, r.id, s.policy.AddOnChip)
+}
+
+//reEnter 被淘汰后重新参赛(新的参赛,重购/加码次数重新计算)
+func (c *Holdem) reEnter(r *Agent) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	s := c.rebuy
+	if s == nil || s.policy.MaxReEntries == 0 || !s.eliminated[r.id] {
+		r.recv.ErrorOccur(c.id, ErrCodeReEntryNotAllowed, errReEntryNotAllowed)
+		return
+	}
+	if !s.inPeriod() {
+		r.recv.ErrorOccur(c.id, ErrCodeRebuyClosed, errRebuyClosed)
+		return
+	}
+	if s.entries[r.id] >= s.policy.MaxReEntries {
+		r.recv.ErrorOccur(c.id, ErrCodeReEntryOverTimes, errReEntryOverTimes)
+		return
+	}
+	s.entries[r.id]++
+	s.eliminated[r.id] = false
+	s.rebuys[r.id] = 0
+	s.addOns[r.id] = false
+	r.gameInfo = &gameInfo{
+		chip:    s.policy.ReEntryChip,
+		bringIn: s.policy.ReEntryChip,
+	}
+	c.addPrize()
+	c.log.Debug("user re-entry", zap.String("user", r.id), zap.Uint("chip", s.policy.ReEntryChip), zap.Uint("entries", s.entries[r.id]))
+	r.recv.PlayerReEntrySuccess(c.id, r.id, s.policy.ReEntryChip, s.entries[r.id])
+}
+
+//applyPendingChips 两手之间把重购/加码/手中带入的筹码加上
+func (c *Holdem) applyPendingChips() {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	for _, r := range c.players {
+		c.applyPendingChip(r)
+	}
+}
+
+//applyPendingChip 把等待中的筹码加到玩家筹码(无锁)
+func (c *Holdem) applyPendingChip(r *Agent) {
+	if r.gameInfo.pendingChip == 0 {
+		return
+	}
+	r.gameInfo.chip += r.gameInfo.pendingChip
+	c.transferChip(playerAccount(r.id), accountCashier, r.gameInfo.pendingChip, "pending chip")
+	r.gameInfo.bringIn += r.gameInfo.pendingChip
+	r.gameInfo.pendingChip = 0
+}
@@ -13,6 +13,8 @@ type Reciever interface {
 	RoomerGamePauseResume(hid string, pausedOrResume bool)
 	//RoomerGameEnd 游戏结束
 	RoomerGameEnd(hid string)
+	//RoomerGetPayouts 接收单桌赛的名次和奖励(单桌赛结束时,在RoomerGameEnd之前)
+	RoomerGetPayouts(hid string, payouts []*Payout)
 	//RoomerPots 当前池
 	RoomerGamePots(hid string, pots []*Pot, round Round)
 	//RoomerExceedTime 延时
@@ -35,6 +37,26 @@ type Reciever interface {
 	RoomerGetWaitInsurance(hid string, seat int8, uid string, dur time.Duration, round Round)
 	//RoomerGetBuyInsurance 接收谁购买了保险的信息
 	RoomerGetBuyInsurance(hid string, seat int8, uid string, buy []*BuyInsurance, round Round)
+	//RoomerGetWaitRunMultiple 接收全下玩家开始选择发牌次数(最多次数,等待时间)
+	RoomerGetWaitRunMultiple(hid string, seats []int8, maxTimes int8, dur time.Duration)
+	//RoomerGetRunMultiple 接收最终发牌次数
+	RoomerGetRunMultiple(hid string, times int8)
+	//RoomerGetRunCards 接收多次发牌中某一次的公共牌(第几次,这次发的牌)
+	RoomerGetRunCards(hid string, run int8, cards []*Card)
+	//RoomerGetSecondBoard 接收双公共牌炸弹底池的第二组公共牌(这次发的牌,和RoomerGetPublicCard同时发)
+	RoomerGetSecondBoard(hid string, cards []*Card)
+	//RoomerGetRabbitCards 接收有人看剩余公共牌(座位号,用户,剩余的公共牌)
+	RoomerGetRabbitCards(hid string, seat int8, userID string, cards []*Card)
+	//RoomerGetBlindLevel 接收盲注级别倒计时(级别序号,当前级别,下一级别(没有为nil),剩余手数,剩余时间),休息开始时也会收到
+	RoomerGetBlindLevel(hid string, level int, current *BlindLevel, next *BlindLevel, hands uint, dur time.Duration)
+	//RoomerGetJackpot 接收坏牌奖池中奖(牌型,每个人的奖金,剩余奖池)
+	RoomerGetJackpot(hid string, hit *JackpotHit)
+	//RoomerGetHighHand 接收高牌型促销(座位,牌型,牌)
+	RoomerGetHighHand(hid string, hands []*HighHand)
+	//RoomerGetFairCommitment 接收可验证公平模式下一手的承诺(手数,原始牌序,哈希),上一手结束时和加入时收到,之后才可以提交玩家种子
+	RoomerGetFairCommitment(hid string, commit *FairCommitment)
+	//RoomerGetFairReveal 接收可验证公平模式一手结束后公开的种子和牌序(可以用VerifyFairHand验证)
+	RoomerGetFairReveal(hid string, reveal *FairReveal)
 	//RoomerGetShowCards 接收亮牌信息
 	RoomerGetShowCards(hid string, cards []*ShowCard)
 	//RoomerGetResult 接收牌局结果
@@ -53,6 +75,16 @@ type Reciever interface {
 	PlayerCanBuyInsurance(hid string, seat int8, userID string, outsLen int, odds float64, outs map[int8][]*UserOut, round Round)
 	//PlayerBuyInsuranceSuccess 玩家购买保险成功（座位号，金额）
 	PlayerBuyInsuranceSuccess(hid string, seat int8, userID string, buy []*BuyInsurance)
+	//PlayerCanRunMultiple 玩家可以选择发牌次数(最多次数,等待时间)
+	PlayerCanRunMultiple(hid string, seat int8, userID string, maxTimes int8, dur time.Duration)
+	//PlayerRunMultipleSuccess 玩家选择发牌次数成功
+	PlayerRunMultipleSuccess(hid string, seat int8, userID string, times int8)
+	//PlayerRebuySuccess 玩家重购成功(筹码下一手开始前加上,第几次重购)
+	PlayerRebuySuccess(hid string, seat int8, userID string, chip uint, times uint)
+	//PlayerAddOnSuccess 玩家加码成功(筹码下一手开始前加上)
+	PlayerAddOnSuccess(hid string, seat int8, userID string, chip uint)
+	//PlayerReEntrySuccess 玩家重新参赛成功(需要重新坐下,第几次重新参赛)
+	PlayerReEntrySuccess(hid string, userID string, chip uint, entries uint)
 	//PlayerBringInSuccess 玩家带入成功
 	PlayerBringInSuccess(hid string, seat int8, userID string, chip uint)
 	//PlayerJoinSuccess 玩家进入游戏成功
@@ -63,12 +95,16 @@ type Reciever interface {
 	PlayerSeatedSuccess(hid string, seat int8, userID string, te PlayType)
 	//PlayerCanPayToPlay 玩家可以补盲了
 	PlayerCanPayToPlay(hid string, seat int8, userID string)
+	//PlayerStraddleSuccess 玩家准备抓头成功(下一手生效)
+	PlayerStraddleSuccess(hid string, seat int8, userID string)
 	//PlayerPayToPlaySuccesss 玩家补盲成功
 	PlayerPayToPlaySuccesss(hid string, seat int8, userID string)
 	//PlayerReadyStandUpSuccess 玩家准备站起成功
 	PlayerReadyStandUpSuccess(hid string, seat int8, userID string)
 	//PlayerStandUp 玩家站起
 	PlayerStandUp(hid string, seat int8, userID string, reasonCode int8)
+	//PlayerSettlement 玩家站起时的结算(总带入,站起时的筹码,输赢),任何原因站起都会收到(换桌时Transfer为true)
+	PlayerSettlement(hid string, s *Settlement)
 	//PlayerKeepSeat 玩家占座(座位号)
 	PlayerKeepSeat(hid string, seat int8, userID string, tm time.Duration)
 	//PlayerExceedTimeSuccess 玩家延时成功
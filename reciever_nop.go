@@ -42,6 +42,38 @@ func (c *NopReciever) RoomerGetWaitInsurance(hid string, seat int8, uid string,
 func (c *NopReciever) RoomerGetBuyInsurance(hid string, seat int8, uid string, buy []*BuyInsurance, round Round) {
 }
 
+//RoomerGetWaitRunMultiple 接收全下玩家开始选择发牌次数
+func (c *NopReciever) RoomerGetWaitRunMultiple(hid string, seats []int8, maxTimes int8, dur time.Duration) {
+}
+
+//RoomerGetRunMultiple 接收最终发牌次数
+func (c *NopReciever) RoomerGetRunMultiple(hid string, times int8) {}
+
+//RoomerGetRunCards 接收多次发牌中某一次的公共牌
+func (c *NopReciever) RoomerGetRunCards(hid string, run int8, cards []*Card) {}
+
+//RoomerGetSecondBoard 接收双公共牌的第二组公共牌
+func (c *NopReciever) RoomerGetSecondBoard(hid string, cards []*Card) {}
+
+//RoomerGetRabbitCards 接收有人看剩余公共牌
+func (c *NopReciever) RoomerGetRabbitCards(hid string, seat int8, userID string, cards []*Card) {}
+
+//RoomerGetBlindLevel 接收盲注级别倒计时
+func (c *NopReciever) RoomerGetBlindLevel(hid string, level int, current *BlindLevel, next *BlindLevel, hands uint, dur time.Duration) {
+}
+
+//RoomerGetJackpot 接收坏牌奖池中奖
+func (c *NopReciever) RoomerGetJackpot(hid string, hit *JackpotHit) {}
+
+//RoomerGetHighHand 接收高牌型促销
+func (c *NopReciever) RoomerGetHighHand(hid string, hands []*HighHand) {}
+
+//RoomerGetFairCommitment 接收可验证公平模式发牌前的承诺
+func (c *NopReciever) RoomerGetFairCommitment(hid string, commit *FairCommitment) {}
+
+//RoomerGetFairReveal 接收可验证公平模式一手结束后公开的种子和牌序
+func (c *NopReciever) RoomerGetFairReveal(hid string, reveal *FairReveal) {}
+
 //RoomerGetShowCards 接收亮牌信息
 func (c *NopReciever) RoomerGetShowCards(hid string, sc []*ShowCard) {}
 
@@ -71,6 +103,23 @@ func (c *NopReciever) PlayerCanBuyInsurance(hid string, seat int8, userID string
 func (c *NopReciever) PlayerBuyInsuranceSuccess(hid string, seat int8, userID string, buy []*BuyInsurance) {
 }
 
+//PlayerCanRunMultiple 玩家可以选择发牌次数
+func (c *NopReciever) PlayerCanRunMultiple(hid string, seat int8, userID string, maxTimes int8, dur time.Duration) {
+}
+
+//PlayerRunMultipleSuccess 玩家选择发牌次数成功
+func (c *NopReciever) PlayerRunMultipleSuccess(hid string, seat int8, userID string, times int8) {}
+
+//PlayerRebuySuccess 玩家重购成功
+func (c *NopReciever) PlayerRebuySuccess(hid string, seat int8, userID string, chip uint, times uint) {
+}
+
+//PlayerAddOnSuccess 玩家加码成功
+func (c *NopReciever) PlayerAddOnSuccess(hid string, seat int8, userID string, chip uint) {}
+
+//PlayerReEntrySuccess 玩家重新参赛成功
+func (c *NopReciever) PlayerReEntrySuccess(hid string, userID string, chip uint, entries uint) {}
+
 //PlayerBringInSuccess 玩家带入成功
 func (c *NopReciever) PlayerBringInSuccess(hid string, seat int8, userID string, chip uint) {}
 
@@ -89,12 +138,18 @@ func (c *NopReciever) PlayerCanPayToPlay(hid string, seat int8, userID string) {
 //PlayerPayToPlaySuccesss 玩家补盲成功
 func (c *NopReciever) PlayerPayToPlaySuccesss(hid string, seat int8, userID string) {}
 
+//PlayerStraddleSuccess 玩家准备抓头成功(下一手生效)
+func (c *NopReciever) PlayerStraddleSuccess(hid string, seat int8, userID string) {}
+
 //PlayerReadyStandUpSuccess 玩家准备站起成功
 func (c *NopReciever) PlayerReadyStandUpSuccess(hid string, seat int8, userID string) {}
 
 //PlayerStandUp 玩家站起
 func (c *NopReciever) PlayerStandUp(hid string, seat int8, userID string, reasonCode int8) {}
 
+//PlayerSettlement 玩家站起时的结算
+func (c *NopReciever) PlayerSettlement(hid string, s *Settlement) {}
+
 //PlayerKeepSeat 玩家占座(座位号)
 func (c *NopReciever) PlayerKeepSeat(hid string, seat int8, userID string, tm time.Duration) {}
 
@@ -104,6 +159,9 @@ func (c *NopReciever) RoomerGameStart(hid string) {}
 //RoomerGameEnd 游戏结束
 func (c *NopReciever) RoomerGameEnd(hid string) {}
 
+//RoomerGetPayouts 接收单桌赛的名次和奖励
+func (c *NopReciever) RoomerGetPayouts(hid string, payouts []*Payout) {}
+
 //RoomerPots 当前池
 func (c *NopReciever) RoomerGamePots(hid string, pots []*Pot, round Round) {}
 
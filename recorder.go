@@ -3,10 +3,18 @@ package holdem
 type Recorder interface {
 	GameStart(*HoldemBase)
 	HandBegin(*HoldemState)
+	ShuffleEntropy(base *HoldemBase, entropy []byte)
 	Ante(base *HoldemBase, seat int8, id string, chip uint, num uint)
 	Action(base *HoldemBase, round Round, seat int8, id string, chip uint, action ActionDef, num uint)
 	InsureResult(base *HoldemBase, round Round, seat int8, id string, bet uint, win float64)
+	RabbitHunt(base *HoldemBase, seat int8, id string, chip uint, cost uint, cards []*Card)
+	Bounty(base *HoldemBase, awards []*BountyAward)
+	Jackpot(base *HoldemBase, hit *JackpotHit)
+	HighHand(base *HoldemBase, hands []*HighHand)
+	LedgerViolation(base *HoldemBase, violations []*LedgerViolation)
+	Settlement(base *HoldemBase, s *Settlement)
 	HandEnd(state *HoldemState, r []*Result)
+	Payout(base *HoldemBase, payouts []*Payout)
 	GameEnd(base *HoldemBase)
 }
 
@@ -19,8 +27,12 @@ func (c *NopRecorder) GameStart(*HoldemBase) {}
 
 func (c *NopRecorder) GameEnd(*HoldemBase) {}
 
+func (c *NopRecorder) Payout(base *HoldemBase, payouts []*Payout) {}
+
 func (c *NopRecorder) HandBegin(*HoldemState) {}
 
+func (c *NopRecorder) ShuffleEntropy(base *HoldemBase, entropy []byte) {}
+
 func (c *NopRecorder) Ante(meta *HoldemBase, seat int8, id string, chip uint, num uint) {
 }
 
@@ -30,4 +42,17 @@ func (c *NopRecorder) Action(meta *HoldemBase, round Round, seat int8, id string
 func (c *NopRecorder) InsureResult(meta *HoldemBase, round Round, seat int8, id string, bet uint, win float64) {
 }
 
+func (c *NopRecorder) RabbitHunt(meta *HoldemBase, seat int8, id string, chip uint, cost uint, cards []*Card) {
+}
+
+func (c *NopRecorder) Bounty(base *HoldemBase, awards []*BountyAward) {}
+
+func (c *NopRecorder) Jackpot(base *HoldemBase, hit *JackpotHit) {}
+
+func (c *NopRecorder) HighHand(base *HoldemBase, hands []*HighHand) {}
+
+func (c *NopRecorder) LedgerViolation(base *HoldemBase, violations []*LedgerViolation) {}
+
+func (c *NopRecorder) Settlement(base *HoldemBase, s *Settlement) {}
+
 func (c *NopRecorder) HandEnd(state *HoldemState, r []*Result) {}
@@ -0,0 +1,109 @@
+package holdem
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+//runMultiple 全下亮牌后选择多次发牌,返回是否已经结算
+func (c *Holdem) runMultiple(users []*Agent) bool {
+	if c.options.maxRunTimes < 2 || c.runVoted || c.doubleBoard() {
+		return false
+	}
+	//每手只选择一次
+	c.runVoted = true
+	left := 5 - len(c.publicCards)
+	if left <= 0 {
+		return false
+	}
+	max := c.options.maxRunTimes
+	//剩余的牌不够发(每条街要烧一张牌)
+	idx, total := c.poker.State()
+	need := left + 3
+	if len(c.publicCards) > 0 {
+		need = left * 2
+	}
+	if n := int8((total - idx) / need); n < max {
+		max = n
+	}
+	if max < 2 {
+		return false
+	}
+	times := c.voteRunTimes(users, max)
+	c.seatLock.Lock()
+	for _, r := range c.roomers {
+		r.recv.RoomerGetRunMultiple(c.id, times)
+	}
+	c.seatLock.Unlock()
+	if times < 2 {
+		return false
+	}
+	boards := make([][]*Card, 0, times)
+	for i := int8(1); i <= times; i++ {
+		board := make([]*Card, 0, 5)
+		board = append(board, c.publicCards...)
+		cards := make([]*Card, 0, left)
+		for len(board) < 5 {
+			n := 1
+			if len(board) == 0 {
+				n = 3
+			}
+			//洗牌
+			_, _ = c.poker.GetCards(1)
+			cds, _ := c.poker.GetCards(n)
+			board = append(board, cds...)
+			cards = append(cards, cds...)
+		}
+		boards = append(boards, board)
+		c.seatLock.Lock()
+		for _, r := range c.roomers {
+			r.recv.RoomerGetRunCards(c.id, i, cards)
+		}
+		c.seatLock.Unlock()
+		c.log.Debug("run cards", zap.Int8("run", i), zap.Int("cards_count", len(cards)))
+	}
+	c.sendPotsInfo(users, RoundRiver)
+	c.complexWin(users, boards...)
+	return true
+}
+
+//voteRunTimes 全下玩家选择发牌次数(取所有人选择的最小值)
+func (c *Holdem) voteRunTimes(users []*Agent, max int8) int8 {
+	c.waitPause()
+	c.log.Debug("run multiple vote begin", zap.Int8("max", max))
+	seats := make([]int8, 0, len(users))
+	for _, u := range users {
+		seats = append(seats, u.gameInfo.seatNumber)
+	}
+	grp, _ := errgroup.WithContext(context.Background())
+	ch := make(chan int8, len(users))
+	timeout := c.options.runWaitTimeout
+	c.addWaitTime(timeout + delaySend)
+	c.seatLock.Lock()
+	for _, r := range c.roomers {
+		r.recv.RoomerGetWaitRunMultiple(c.id, seats, max, timeout)
+	}
+	c.seatLock.Unlock()
+	for _, user := range users {
+		u := user
+		grp.Go(func() error {
+			u.recv.PlayerCanRunMultiple(c.id, u.gameInfo.seatNumber, u.id, max, timeout)
+			t := u.waitRunTimes(max, timeout)
+			u.recv.PlayerRunMultipleSuccess(c.id, u.gameInfo.seatNumber, u.id, t)
+			ch <- t
+			return nil
+		})
+	}
+	_ = grp.Wait()
+	close(ch)
+	times := max
+	for t := range ch {
+		if t < times {
+			times = t
+		}
+	}
+	c.log.Debug("run multiple vote end", zap.Int8("times", times))
+	return times
+}
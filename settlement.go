@@ -0,0 +1,32 @@
+package holdem
+
+//Settlement 站起时的结算(钱包按Chip退回,换桌的除外)
+type Settlement struct {
+	SeatNumber int8
+	UserID     string
+	//Reason 站起原因(StandUp*)
+	Reason int8
+	//BringIn 总带入(包括重购/加码)
+	BringIn uint
+	//Chip 站起时的筹码
+	Chip uint
+	//Net 输赢(Chip - BringIn)
+	Net int64
+	//Transfer 换桌,筹码带到新桌子(钱包不用退回)
+	Transfer bool
+}
+
+//settle 站起时结算并通知(有锁),等待中的筹码在站起前已经加上
+func (c *Holdem) settle(i int8, r *Agent, reason int8) {
+	s := &Settlement{
+		SeatNumber: i,
+		UserID:     r.id,
+		Reason:     reason,
+		BringIn:    r.gameInfo.bringIn,
+		Chip:       r.gameInfo.chip,
+		Net:        int64(r.gameInfo.chip) - int64(r.gameInfo.bringIn),
+		Transfer:   r.gameInfo.transfer != nil && r.gameInfo.chip > 0,
+	}
+	r.recv.PlayerSettlement(c.id, s)
+	c.options.recorder.Settlement(c.base(), s)
+}
@@ -0,0 +1,128 @@
+package holdem
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+//Payout 名次和奖励
+type Payout struct {
+	Position int
+	UserID   string
+	Prize    uint
+}
+
+//sitAndGo 单桌赛状态
+type sitAndGo struct {
+	prizePool  uint
+	payouts    []uint
+	startChips map[*Agent]uint
+	ranks      []*Payout
+	over       bool
+}
+
+func newSitAndGo(prizePool uint, payouts []uint) *sitAndGo {
+	return &sitAndGo{
+		prizePool:  prizePool,
+		payouts:    payouts,
+		startChips: make(map[*Agent]uint),
+		ranks:      make([]*Payout, 0),
+	}
+}
+
+//sngHandBegin 记录每手开始时的筹码(同一手被淘汰的按开始筹码排名)
+func (c *Holdem) sngHandBegin() {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	s := c.sng
+	s.startChips = make(map[*Agent]uint)
+	for _, r := range c.players {
+		s.startChips[r] = r.gameInfo.chip
+	}
+}
+
+//sngEliminate 淘汰输光的玩家,剩一人时结束(有锁)
+//按淘汰顺序记录(同一手开始时筹码少的先淘汰),重新参赛的每次参赛单独排名
+func (c *Holdem) sngEliminate(busted []*Agent) {
+	s := c.sng
+	sort.Slice(busted, func(i, j int) bool {
+		return s.startChips[busted[i]] < s.startChips[busted[j]]
+	})
+	out := make(map[*Agent]bool)
+	for _, r := range busted {
+		out[r] = true
+	}
+	//还有筹码的和等待重购的
+	left, waiting := 0, 0
+	for _, r := range c.players {
+		if r.gameInfo.chip > 0 || r.gameInfo.pendingChip > 0 {
+			left++
+		} else if !out[r] {
+			waiting++
+		}
+	}
+	for _, r := range busted {
+		s.ranks = append(s.ranks, &Payout{
+			UserID: r.ID(),
+		})
+		if c.rebuy != nil {
+			c.rebuy.eliminated[r.id] = true
+		}
+		c.log.Debug("user eliminated", zap.Int8("seat", r.gameInfo.seatNumber), zap.String("user", r.ID()), zap.Int("remaining", left+waiting))
+		c.standUp(r.gameInfo.seatNumber, r, StandUpEliminated)
+	}
+	if left+waiting > 1 || s.over {
+		return
+	}
+	for _, r := range c.players {
+		if r.gameInfo.chip > 0 || r.gameInfo.pendingChip > 0 {
+			s.ranks = append(s.ranks, &Payout{
+				UserID: r.ID(),
+			})
+		}
+	}
+	s.over = true
+}
+
+//sngOver 单桌赛是否已经结束
+func (c *Holdem) sngOver() bool {
+	return c.sng != nil && c.sng.over
+}
+
+//payouts 单桌赛名次奖励(按淘汰顺序倒序排名,零头给第一名,有锁)
+func (c *Holdem) payouts() []*Payout {
+	if c.sng == nil {
+		return nil
+	}
+	s := c.sng
+	//提前结束时还在场上的玩家排在前面(不参与分配)
+	offset := 0
+	if !s.over {
+		offset = len(c.players)
+	}
+	ret := make([]*Payout, 0, len(s.ranks))
+	for i := len(s.ranks) - 1; i >= 0; i-- {
+		p := s.ranks[i]
+		p.Position = offset + len(ret) + 1
+		ret = append(ret, p)
+	}
+	var total uint
+	for _, p := range ret {
+		p.Prize = 0
+		if p.Position <= len(s.payouts) {
+			p.Prize = s.prizePool * s.payouts[p.Position-1] / 100
+			total += p.Prize
+		}
+	}
+	if len(ret) > 0 && ret[0].Position == 1 && total > 0 && total < s.prizePool {
+		var pct uint
+		for _, v := range s.payouts {
+			pct += v
+		}
+		if pct == 100 {
+			ret[0].Prize += s.prizePool - total
+		}
+	}
+	return ret
+}
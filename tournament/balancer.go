@@ -0,0 +1,372 @@
+package tournament
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/whatisfaker/holdem"
+	"go.uber.org/zap"
+)
+
+//Move 一次换桌
+type Move struct {
+	UserID string
+	From   *holdem.Holdem
+	To     *holdem.Holdem
+	Seat   int8
+	//SkipBlinds 新座位会错过盲注
+	SkipBlinds bool
+}
+
+//SeatDraw 抽到的座位
+type SeatDraw struct {
+	UserID string
+	Table  *holdem.Holdem
+	Seat   int8
+}
+
+//Balancer 多桌平衡
+//桌子多余时拆掉人数最少的桌子,人数差超过1时从人最多的桌子移人到人最少的桌子
+//移动下一手的大盲,坐到新桌子最快轮到大盲的空位,同一个玩家不会两次错过盲注
+//换桌通过Holdem.Transfer在原来桌子两手之间带着筹码执行
+type Balancer struct {
+	seatCount int8
+	log       *zap.Logger
+	mu        sync.Mutex
+	tables    []*holdem.Holdem
+	broken    []*holdem.Holdem
+	moving    map[string]*Move //已经要求还没有坐下的换桌
+	skipped   map[string]bool  //换桌时错过过盲注还没有交大盲的玩家
+	out       map[string]bool  //已经淘汰的玩家
+	rd        *rand.Rand
+}
+
+func NewBalancer(seatCount int8, log *zap.Logger) *Balancer {
+	return &Balancer{
+		seatCount: seatCount,
+		log:       log,
+		moving:    make(map[string]*Move),
+		skipped:   make(map[string]bool),
+		out:       make(map[string]bool),
+		rd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//Add 加入桌子
+func (c *Balancer) Add(hs ...*holdem.Holdem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = append(c.tables, hs...)
+}
+
+//Tables 还没有拆掉的桌子
+func (c *Balancer) Tables() []*holdem.Holdem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*holdem.Holdem, len(c.tables))
+	copy(ret, c.tables)
+	return ret
+}
+
+//Eliminate 玩家被淘汰(不再等待他换桌,也不再计算人数)
+func (c *Balancer) Eliminate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.moving, userID)
+	delete(c.skipped, userID)
+	c.out[userID] = true
+}
+
+//Draw 随机抽座位(玩家均匀分到各桌的随机座位)
+func (c *Balancer) Draw(ids []string) ([]*SeatDraw, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.tables)
+	if n == 0 || len(ids) > n*int(c.seatCount) {
+		return nil, ErrNotEnoughSeats
+	}
+	seats := make([][]int, n)
+	for i := range seats {
+		seats[i] = c.rd.Perm(int(c.seatCount))
+	}
+	ret := make([]*SeatDraw, 0, len(ids))
+	for i, idx := range c.rd.Perm(len(ids)) {
+		ret = append(ret, &SeatDraw{
+			UserID: ids[idx],
+			Table:  c.tables[i%n],
+			Seat:   int8(seats[i%n][i/n]) + 1,
+		})
+	}
+	return ret, nil
+}
+
+//tableView 平衡时桌子的人数快照
+type tableView struct {
+	h        *holdem.Holdem
+	state    *holdem.HoldemState
+	seats    map[int8]string //有筹码(或全下)且不在换走的玩家
+	reserved map[int8]bool   //换过来的玩家的座位
+	count    int
+}
+
+//snapshot 按State统计每张桌子的人数(算上正在换过来的,不算正在换走的)
+func (c *Balancer) snapshot(hs []*holdem.Holdem) []*tableView {
+	ret := make([]*tableView, 0, len(hs))
+	for _, h := range hs {
+		v := &tableView{
+			h:        h,
+			state:    h.State(),
+			seats:    make(map[int8]string),
+			reserved: make(map[int8]bool),
+		}
+		for _, u := range v.state.Seated {
+			if c.out[u.ID] {
+				continue
+			}
+			if m, ok := c.moving[u.ID]; ok {
+				if m.To != h {
+					continue
+				}
+				//已经坐下
+				delete(c.moving, u.ID)
+			}
+			//在新桌子交过大盲后可以再错过一次盲注
+			if c.skipped[u.ID] && u.HandNum > 0 && u.SeatNumber == v.state.BBSeat {
+				delete(c.skipped, u.ID)
+			}
+			//全下的玩家这手还没有结束
+			if u.Chip > 0 || u.Status == holdem.ActionDefAllIn {
+				v.seats[u.SeatNumber] = u.ID
+			}
+		}
+		v.count = len(v.seats)
+		ret = append(ret, v)
+	}
+	for _, m := range c.moving {
+		for _, v := range ret {
+			if m.To == v.h {
+				v.reserved[m.Seat] = true
+				v.count++
+			}
+		}
+	}
+	return ret
+}
+
+//Balance 平衡人数,返回这次要求的换桌和已经拆完(玩家都要求换走)的桌子
+func (c *Balancer) Balance() ([]*Move, []*holdem.Holdem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	moves := make([]*Move, 0)
+	views := c.snapshot(c.tables)
+	total := 0
+	for _, v := range views {
+		total += v.count
+	}
+	sc := int(c.seatCount)
+	need := (total + sc - 1) / sc
+	if need < 1 {
+		need = 1
+	}
+	//拆桌(不拆还有人在换过来的桌子)
+	for len(views) > need {
+		var s *tableView
+		for _, v := range views {
+			if len(v.reserved) == 0 && (s == nil || v.count < s.count) {
+				s = v
+			}
+		}
+		if s == nil {
+			break
+		}
+		views = c.remove(views, s)
+		c.broken = append(c.broken, s.h)
+		c.log.Debug("balancer break table", zap.String("table", s.h.ID()), zap.Int("players", s.count))
+	}
+	//拆掉的桌子上的玩家全部换走,都要求换走后才算拆完(没有空位的下次平衡时再换)
+	broken := make([]*holdem.Holdem, 0, len(c.broken))
+	left := make([]*holdem.Holdem, 0, len(c.broken))
+	for _, s := range c.snapshot(c.broken) {
+		for _, id := range c.order(s) {
+			dest, full := c.destination(views, id)
+			if full {
+				c.log.Error("no table for player", zap.String("user", id), zap.String("from", s.h.ID()))
+				break
+			}
+			//错过过盲注的玩家只有会错过盲注的空位时等下次平衡
+			if dest == nil {
+				c.log.Debug("balancer wait seat", zap.String("user", id), zap.String("from", s.h.ID()))
+				continue
+			}
+			if m := c.move(s, dest, id); m != nil {
+				moves = append(moves, m)
+			}
+		}
+		if len(s.seats) > 0 {
+			left = append(left, s.h)
+			continue
+		}
+		broken = append(broken, s.h)
+	}
+	c.broken = left
+	//人数差超过1时移人
+	for {
+		var max, min *tableView
+		for _, v := range views {
+			if max == nil || v.count > max.count {
+				max = v
+			}
+			if min == nil || v.count < min.count {
+				min = v
+			}
+		}
+		if max == nil || max.count-min.count <= 1 {
+			break
+		}
+		id := c.candidate(max, min)
+		if id == "" {
+			break
+		}
+		m := c.move(max, min, id)
+		if m == nil {
+			break
+		}
+		moves = append(moves, m)
+	}
+	return moves, broken
+}
+
+//remove 去掉拆掉的桌子
+func (c *Balancer) remove(views []*tableView, s *tableView) []*tableView {
+	ret := make([]*tableView, 0, len(views))
+	for _, v := range views {
+		if v != s {
+			ret = append(ret, v)
+		}
+	}
+	tables := make([]*holdem.Holdem, 0, len(c.tables))
+	for _, h := range c.tables {
+		if h != s.h {
+			tables = append(tables, h)
+		}
+	}
+	c.tables = tables
+	return ret
+}
+
+//order 从下一手的大盲开始的玩家顺序
+func (c *Balancer) order(v *tableView) []string {
+	start := v.state.BBSeat + 1
+	ret := make([]string, 0, len(v.seats))
+	for i := int8(0); i < c.seatCount; i++ {
+		seat := (start+i-1)%c.seatCount + 1
+		if id, ok := v.seats[seat]; ok {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+//candidate 要移走的玩家(下一手的大盲,错过过盲注的不再移到会错过盲注的位置,没有可以移的返回"")
+func (c *Balancer) candidate(from *tableView, to *tableView) string {
+	for _, id := range c.order(from) {
+		if seat, _ := c.worstSeat(to, c.skipped[id]); seat > 0 {
+			return id
+		}
+	}
+	return ""
+}
+
+//destination 拆桌时玩家换去的桌子(有空位的桌子中人最少的),full为所有桌子都没有空位
+//错过过盲注的玩家只换到有不会错过盲注的空位的桌子,没有时返回nil
+func (c *Balancer) destination(views []*tableView, id string) (*tableView, bool) {
+	var dest *tableView
+	full := true
+	for _, v := range views {
+		if v.count >= int(c.seatCount) {
+			continue
+		}
+		full = false
+		if seat, _ := c.worstSeat(v, c.skipped[id]); seat == 0 {
+			continue
+		}
+		if dest == nil || v.count < dest.count {
+			dest = v
+		}
+	}
+	return dest, full
+}
+
+//worstSeat 最快轮到大盲的空位,以及是否会错过盲注(在庄位和小盲之间),noSkip时只找不会错过盲注的空位
+func (c *Balancer) worstSeat(v *tableView, noSkip bool) (int8, bool) {
+	st := v.state
+	for i := int8(1); i <= c.seatCount; i++ {
+		seat := (st.BBSeat+i-1)%c.seatCount + 1
+		if _, ok := v.seats[seat]; ok || v.reserved[seat] || !c.empty(st, seat) {
+			continue
+		}
+		skip := st.ButtonSeat > 0 && between(st.ButtonSeat, st.SBSeat, seat, c.seatCount)
+		if skip && noSkip {
+			continue
+		}
+		return seat, skip
+	}
+	return 0, false
+}
+
+//empty 座位是否为空(没有筹码等待站起的也算占着)
+func (c *Balancer) empty(st *holdem.HoldemState, seat int8) bool {
+	for _, s := range st.EmptySeats {
+		if s == seat {
+			return true
+		}
+	}
+	return false
+}
+
+//move 要求换桌并更新快照
+func (c *Balancer) move(from *tableView, to *tableView, id string) *Move {
+	seat, skip := c.worstSeat(to, c.skipped[id])
+	if seat == 0 {
+		return nil
+	}
+	if err := from.h.Transfer(id, to.h, seat); err != nil {
+		c.log.Error("transfer player", zap.String("user", id), zap.String("from", from.h.ID()), zap.Error(err))
+		return nil
+	}
+	m := &Move{
+		UserID:     id,
+		From:       from.h,
+		To:         to.h,
+		Seat:       seat,
+		SkipBlinds: skip,
+	}
+	c.moving[id] = m
+	if skip {
+		c.skipped[id] = true
+	}
+	for s, u := range from.seats {
+		if u == id {
+			delete(from.seats, s)
+		}
+	}
+	from.count--
+	to.reserved[seat] = true
+	to.count++
+	c.log.Debug("balancer move", zap.String("user", id), zap.String("from", from.h.ID()), zap.String("to", to.h.ID()), zap.Int8("seat", seat), zap.Bool("skip", skip))
+	return m
+}
+
+//between 座位是否在两个座位之间(顺时针,不含两端)
+func between(from int8, to int8, seat int8, sc int8) bool {
+	for s := from%sc + 1; s != to; s = s%sc + 1 {
+		if s == seat {
+			return true
+		}
+		if s == from {
+			break
+		}
+	}
+	return false
+}
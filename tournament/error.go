@@ -0,0 +1,14 @@
+package tournament
+
+import "errors"
+
+var (
+	ErrTournamentStarted  = errors.New("tournament is already started")
+	ErrPlayerRegistered   = errors.New("player is already registered")
+	ErrNotEnoughPlayers   = errors.New("not enough players")
+	ErrNoLevels           = errors.New("no blind levels")
+	ErrInvalidLevel       = errors.New("blind levels must advance by duration, a break needs a duration and can not be the first level")
+	ErrInvalidSeatCount   = errors.New("seat count must be at least 2")
+	ErrTournamentFinished = errors.New("tournament is finished")
+	ErrNotEnoughSeats     = errors.New("not enough seats")
+)
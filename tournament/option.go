@@ -0,0 +1,60 @@
+package tournament
+
+import (
+	"time"
+
+	"github.com/whatisfaker/holdem"
+)
+
+type extOptions struct {
+	recorder                Recorder
+	handRecorder            holdem.Recorder
+	tableOptions            []holdem.HoldemOption
+	waitForNotEnoughPlayers time.Duration //桌子人数不够等待时间
+}
+
+type Option interface {
+	apply(*extOptions)
+}
+
+type funcOption struct {
+	f func(*extOptions)
+}
+
+func newFuncOption(f func(*extOptions)) *funcOption {
+	return &funcOption{
+		f: f,
+	}
+}
+
+func (fo *funcOption) apply(do *extOptions) {
+	fo.f(do)
+}
+
+//OptionRecorder 比赛事件记录
+func OptionRecorder(rc Recorder) Option {
+	return newFuncOption(func(o *extOptions) {
+		o.recorder = rc
+	})
+}
+
+//OptionHandRecorder 每张桌子牌局的记录(比赛会包装它来跟踪筹码)
+func OptionHandRecorder(rc holdem.Recorder) Option {
+	return newFuncOption(func(o *extOptions) {
+		o.handRecorder = rc
+	})
+}
+
+//OptionTableOptions 每张桌子额外的配置
+func OptionTableOptions(ops ...holdem.HoldemOption) Option {
+	return newFuncOption(func(o *extOptions) {
+		o.tableOptions = append(o.tableOptions, ops...)
+	})
+}
+
+//OptionWaitForNotEnoughPlayers 桌子人数不够时的等待时间
+func OptionWaitForNotEnoughPlayers(dur time.Duration) Option {
+	return newFuncOption(func(o *extOptions) {
+		o.waitForNotEnoughPlayers = dur
+	})
+}
@@ -0,0 +1,35 @@
+package tournament
+
+import "github.com/whatisfaker/holdem"
+
+type Recorder interface {
+	//TournamentStart 比赛开始(桌子ID)
+	TournamentStart(tid string, tables []string)
+	//LevelChange 升盲(第几级,级别信息),休息开始时也会收到
+	LevelChange(tid string, level int, l *holdem.BlindLevel)
+	//PlayerMoved 玩家换桌
+	PlayerMoved(tid string, userID string, from string, to string)
+	//TableBroken 拆桌
+	TableBroken(tid string, table string)
+	//PlayerEliminated 玩家被淘汰
+	PlayerEliminated(tid string, f *Finish)
+	//TournamentEnd 比赛结束(最终名次)
+	TournamentEnd(tid string, ledger []*Finish)
+}
+
+type NopRecorder struct {
+}
+
+var _ Recorder = (*NopRecorder)(nil)
+
+func (c *NopRecorder) TournamentStart(tid string, tables []string) {}
+
+func (c *NopRecorder) LevelChange(tid string, level int, l *holdem.BlindLevel) {}
+
+func (c *NopRecorder) PlayerMoved(tid string, userID string, from string, to string) {}
+
+func (c *NopRecorder) TableBroken(tid string, table string) {}
+
+func (c *NopRecorder) PlayerEliminated(tid string, f *Finish) {}
+
+func (c *NopRecorder) TournamentEnd(tid string, ledger []*Finish) {}
@@ -0,0 +1,32 @@
+package tournament
+
+import (
+	"github.com/whatisfaker/holdem"
+)
+
+//table 比赛中的一张桌子
+type table struct {
+	id         string
+	h          *holdem.Holdem
+	broken     bool            //已经拆桌
+	startChips map[string]uint //本手开始时的筹码
+}
+
+//tableRecorder 包装牌局记录,跟踪每手的筹码
+type tableRecorder struct {
+	holdem.Recorder
+	t  *Tournament
+	tb *table
+}
+
+var _ holdem.Recorder = (*tableRecorder)(nil)
+
+func (c *tableRecorder) HandBegin(state *holdem.HoldemState) {
+	c.Recorder.HandBegin(state)
+	c.t.handBegin(c.tb, state)
+}
+
+func (c *tableRecorder) HandEnd(state *holdem.HoldemState, r []*holdem.Result) {
+	c.Recorder.HandEnd(state, r)
+	c.t.handEnd(c.tb, state)
+}
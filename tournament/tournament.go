@@ -0,0 +1,442 @@
+package tournament
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/whatisfaker/holdem"
+	"go.uber.org/zap"
+)
+
+//Finish 名次记录
+type Finish struct {
+	Position int
+	UserID   string
+	TableID  string
+	//HandNum 被淘汰的手数(所在桌子)
+	HandNum uint
+	//Chip 被淘汰那一手开始时的筹码
+	Chip uint
+	Time time.Time
+}
+
+type player struct {
+	id    string
+	agent *holdem.Agent
+	table *table
+	chip  uint
+	out   bool
+}
+
+type Tournament struct {
+	id             string
+	seatCount      int8
+	startingStack  uint
+	levels         holdem.BlindSchedule
+	waitBetTimeout time.Duration
+	log            *zap.Logger
+	options        *extOptions
+	balancer       *Balancer
+	mu             sync.Mutex
+	players        map[string]*player
+	registered     []*player
+	tables         []*table
+	level          int
+	started        bool
+	finished       bool
+	ledger         []*Finish
+	eliminated     []string //还没有通知平衡器的淘汰玩家
+	done           chan struct{}
+}
+
+func NewTournament(
+	id string,
+	seatCount int8, //每桌座位数
+	startingStack uint, //起始筹码
+	levels holdem.BlindSchedule, //盲注表(按时间升级,休息时暂停所有桌子)
+	waitBetTimeout time.Duration, //等待下注超时时间
+	log *zap.Logger, //日志
+	ops ...Option,
+) *Tournament {
+	exts := &extOptions{
+		recorder:                &NopRecorder{},
+		handRecorder:            &holdem.NopRecorder{},
+		waitForNotEnoughPlayers: time.Second,
+	}
+	for _, o := range ops {
+		o.apply(exts)
+	}
+	return &Tournament{
+		id:             id,
+		seatCount:      seatCount,
+		startingStack:  startingStack,
+		levels:         levels,
+		waitBetTimeout: waitBetTimeout,
+		log:            log,
+		options:        exts,
+		balancer:       NewBalancer(seatCount, log),
+		players:        make(map[string]*player),
+		done:           make(chan struct{}),
+	}
+}
+
+//ID 比赛标识
+func (c *Tournament) ID() string {
+	return c.id
+}
+
+//Register 报名(返回比赛中使用的Agent)
+func (c *Tournament) Register(id string, recv holdem.Reciever, log *zap.Logger) (*holdem.Agent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.started {
+		return nil, ErrTournamentStarted
+	}
+	if _, ok := c.players[id]; ok {
+		return nil, ErrPlayerRegistered
+	}
+	p := &player{
+		id:   id,
+		chip: c.startingStack,
+	}
+	p.agent = holdem.NewAgent(recv, id, log)
+	c.players[id] = p
+	c.registered = append(c.registered, p)
+	return p.agent, nil
+}
+
+//Start 开桌并开始比赛
+func (c *Tournament) Start() error {
+	c.mu.Lock()
+	if c.started {
+		c.mu.Unlock()
+		return ErrTournamentStarted
+	}
+	if c.seatCount < 2 {
+		c.mu.Unlock()
+		return ErrInvalidSeatCount
+	}
+	if len(c.levels) == 0 {
+		c.mu.Unlock()
+		return ErrNoLevels
+	}
+	if err := validLevels(c.levels); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	if len(c.registered) < 2 {
+		c.mu.Unlock()
+		return ErrNotEnoughPlayers
+	}
+	c.started = true
+	sc := int(c.seatCount)
+	n := (len(c.registered) + sc - 1) / sc
+	lv := c.levels[0]
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		tb := &table{
+			id: fmt.Sprintf("%s-%d", c.id, i+1),
+		}
+		ops := make([]holdem.HoldemOption, 0, len(c.options.tableOptions)+5)
+		ops = append(ops, c.options.tableOptions...)
+		ops = append(ops,
+			holdem.OptionAnte(lv.Ante),
+			holdem.OptionCustomRecorder(&tableRecorder{
+				Recorder: c.options.handRecorder,
+				t:        c,
+				tb:       tb,
+			}),
+			holdem.OptionAllowShortStack(),
+			holdem.OptionWaitForRebuy(0),
+			holdem.OptionWaitForNotEnoughPlayers(c.options.waitForNotEnoughPlayers),
+		)
+		tb.h = holdem.NewHoldem(tb.id, c.seatCount, lv.SmallBlind, c.waitBetTimeout, c.nextGame(tb), c.log.With(zap.String("table", tb.id)), ops...)
+		tb.h.ChangeBlindLevel(lv)
+		c.tables = append(c.tables, tb)
+		c.balancer.Add(tb.h)
+		ids = append(ids, tb.id)
+	}
+	//随机抽座位
+	uids := make([]string, 0, len(c.registered))
+	for _, p := range c.registered {
+		uids = append(uids, p.id)
+	}
+	draws, err := c.balancer.Draw(uids)
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	for _, d := range draws {
+		c.players[d.UserID].table = c.tableOf(d.Table)
+	}
+	c.mu.Unlock()
+	for _, d := range draws {
+		p := c.players[d.UserID]
+		p.agent.Join(d.Table)
+		p.agent.BringIn(p.chip)
+		p.agent.Seated(d.Seat)
+	}
+	c.options.recorder.TournamentStart(c.id, ids)
+	go c.levelLoop()
+	for _, tb := range c.tables {
+		tb.h.Start()
+	}
+	return nil
+}
+
+//Level 当前盲注级别
+func (c *Tournament) Level() (int, *holdem.BlindLevel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.level, c.levels[c.level]
+}
+
+//Remaining 剩余玩家数
+func (c *Tournament) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remaining()
+}
+
+//Finished 比赛是否结束
+func (c *Tournament) Finished() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.finished
+}
+
+//Tables 还在进行的桌子
+func (c *Tournament) Tables() []*holdem.Holdem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*holdem.Holdem, 0, len(c.tables))
+	for _, tb := range c.activeTables() {
+		ret = append(ret, tb.h)
+	}
+	return ret
+}
+
+//Ledger 名次记录(按名次排序)
+func (c *Tournament) Ledger() []*Finish {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]*Finish, len(c.ledger))
+	copy(ret, c.ledger)
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Position < ret[j].Position
+	})
+	return ret
+}
+
+//validLevels 比赛的盲注表只按时间升级,第一级不能是休息,休息要有时间
+func validLevels(levels holdem.BlindSchedule) error {
+	for i, lv := range levels {
+		if lv.Hands > 0 || (lv.Break && (i == 0 || lv.Duration <= 0)) {
+			return ErrInvalidLevel
+		}
+	}
+	return nil
+}
+
+//levelLoop 按时间升盲,休息时暂停所有桌子到休息结束
+func (c *Tournament) levelLoop() {
+	for {
+		c.mu.Lock()
+		lv := c.levels[c.level]
+		c.mu.Unlock()
+		if lv.Duration <= 0 {
+			return
+		}
+		timer := time.NewTimer(lv.Duration)
+		select {
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		c.mu.Lock()
+		if c.finished || c.level+1 >= len(c.levels) {
+			c.mu.Unlock()
+			return
+		}
+		c.level++
+		level := c.level
+		next := c.levels[level]
+		tables := c.activeTables()
+		c.mu.Unlock()
+		for _, tb := range tables {
+			switch {
+			case next.Break:
+				tb.h.Pause()
+			case lv.Break:
+				tb.h.ChangeBlindLevel(next)
+				tb.h.Resume()
+			default:
+				tb.h.ChangeBlindLevel(next)
+			}
+		}
+		c.log.Debug("level change", zap.Int("level", level), zap.Uint("sb", next.SmallBlind), zap.Uint("ante", next.Ante), zap.Bool("break", next.Break))
+		c.options.recorder.LevelChange(c.id, level, next)
+	}
+}
+
+//handBegin 记录每手开始时的筹码
+func (c *Tournament) handBegin(tb *table, state *holdem.HoldemState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tb.startChips = make(map[string]uint)
+	for _, u := range state.Seated {
+		tb.startChips[u.ID] = u.Chip
+		//换桌过来的玩家
+		if p, ok := c.players[u.ID]; ok {
+			p.table = tb
+		}
+	}
+}
+
+//handEnd 更新筹码,淘汰输光的玩家(同一手被淘汰的,开始时筹码多的名次靠前)
+func (c *Tournament) handEnd(tb *table, state *holdem.HoldemState) {
+	c.mu.Lock()
+	for _, u := range state.Seated {
+		if p, ok := c.players[u.ID]; ok && p.table == tb {
+			p.chip = u.Chip
+		}
+	}
+	busted := make([]*player, 0)
+	for id, chip := range tb.startChips {
+		if p, ok := c.players[id]; ok && chip > 0 && !p.out && p.table == tb && p.chip == 0 {
+			busted = append(busted, p)
+		}
+	}
+	sort.Slice(busted, func(i, j int) bool {
+		return tb.startChips[busted[i].id] < tb.startChips[busted[j].id]
+	})
+	now := time.Now()
+	finishes := make([]*Finish, 0, len(busted)+1)
+	remaining := c.remaining()
+	for _, p := range busted {
+		p.out = true
+		c.eliminated = append(c.eliminated, p.id)
+		finishes = append(finishes, &Finish{
+			Position: remaining,
+			UserID:   p.id,
+			TableID:  tb.id,
+			HandNum:  state.HandNum,
+			Chip:     tb.startChips[p.id],
+			Time:     now,
+		})
+		remaining--
+	}
+	var stops []*table
+	if remaining == 1 && !c.finished {
+		for _, p := range c.players {
+			if !p.out {
+				finishes = append(finishes, &Finish{
+					Position: 1,
+					UserID:   p.id,
+					TableID:  p.table.id,
+					HandNum:  state.HandNum,
+					Chip:     p.chip,
+					Time:     now,
+				})
+			}
+		}
+		c.finished = true
+		close(c.done)
+		stops = c.tables
+	}
+	c.ledger = append(c.ledger, finishes...)
+	c.mu.Unlock()
+	for _, f := range finishes {
+		if f.Position > 1 {
+			c.log.Debug("player eliminated", zap.String("user", f.UserID), zap.Int("position", f.Position))
+			c.options.recorder.PlayerEliminated(c.id, f)
+		}
+	}
+	if stops != nil {
+		for _, s := range stops {
+			s.h.Stop()
+		}
+		c.log.Debug("tournament end")
+		c.options.recorder.TournamentEnd(c.id, c.Ledger())
+	}
+}
+
+//nextGame 每手结束后拆桌/平衡人数
+func (c *Tournament) nextGame(tb *table) func(*holdem.HoldemState) bool {
+	return func(*holdem.HoldemState) bool {
+		c.balance()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return !c.finished && !tb.broken
+	}
+}
+
+//balance 拆桌/移人(换桌在原来桌子两手之间执行)
+//handEnd在桌子的锁内调用,淘汰的玩家在这里才通知平衡器,避免和平衡器读取桌子状态时互相等锁
+func (c *Tournament) balance() {
+	c.mu.Lock()
+	if c.finished {
+		c.mu.Unlock()
+		return
+	}
+	eliminated := c.eliminated
+	c.eliminated = nil
+	c.mu.Unlock()
+	for _, id := range eliminated {
+		c.balancer.Eliminate(id)
+	}
+	moves, broken := c.balancer.Balance()
+	c.mu.Lock()
+	tables := make([]*table, 0, len(broken))
+	for _, h := range broken {
+		if tb := c.tableOf(h); tb != nil {
+			tb.broken = true
+			tables = append(tables, tb)
+		}
+	}
+	c.mu.Unlock()
+	for _, tb := range tables {
+		c.log.Debug("table broken", zap.String("table", tb.id))
+		tb.h.Stop()
+		c.options.recorder.TableBroken(c.id, tb.id)
+	}
+	for _, m := range moves {
+		c.log.Debug("player moved", zap.String("user", m.UserID), zap.String("from", m.From.ID()), zap.String("to", m.To.ID()), zap.Int8("seat", m.Seat))
+		c.options.recorder.PlayerMoved(c.id, m.UserID, m.From.ID(), m.To.ID())
+	}
+}
+
+//tableOf 桌子(无锁)
+func (c *Tournament) tableOf(h *holdem.Holdem) *table {
+	for _, tb := range c.tables {
+		if tb.h == h {
+			return tb
+		}
+	}
+	return nil
+}
+
+//remaining 剩余玩家数(无锁)
+func (c *Tournament) remaining() int {
+	n := 0
+	for _, p := range c.players {
+		if !p.out {
+			n++
+		}
+	}
+	return n
+}
+
+//activeTables 未拆的桌子(无锁)
+func (c *Tournament) activeTables() []*table {
+	ret := make([]*table, 0, len(c.tables))
+	for _, tb := range c.tables {
+		if !tb.broken {
+			ret = append(ret, tb)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,373 @@
+package tournament
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/whatisfaker/holdem"
+	"go.uber.org/zap"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+//testTables 按人数创建桌子并坐下(玩家ID为"桌号-座位号")
+func testTables(sc int8, counts ...int) []*holdem.Holdem {
+	hs := make([]*holdem.Holdem, 0, len(counts))
+	for i, n := range counts {
+		h := holdem.NewHoldem(fmt.Sprintf("t%d", i+1), sc, 10, time.Second, func(*holdem.HoldemState) bool { return false }, zap.NewNop())
+		for s := 1; s <= n; s++ {
+			r := holdem.NewAgent(&holdem.NopReciever{}, fmt.Sprintf("%d-%d", i+1, s), zap.NewNop())
+			r.Join(h)
+			r.BringIn(1000)
+			r.Seated(int8(s))
+		}
+		hs = append(hs, h)
+	}
+	return hs
+}
+
+func TestBalancerDraw(t *testing.T) {
+	assert := assert.New(t)
+	b := NewBalancer(4, zap.NewNop())
+	b.Add(testTables(4, 0, 0, 0)...)
+	ids := make([]string, 10)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+	draws, err := b.Draw(ids)
+	assert.Nil(err)
+	assert.Equal(10, len(draws))
+	counts := make(map[*holdem.Holdem]int)
+	seats := make(map[string]bool)
+	users := make(map[string]bool)
+	for _, d := range draws {
+		counts[d.Table]++
+		key := fmt.Sprintf("%s-%d", d.Table.ID(), d.Seat)
+		assert.False(seats[key])
+		assert.True(d.Seat >= 1 && d.Seat <= 4)
+		seats[key] = true
+		users[d.UserID] = true
+	}
+	assert.Equal(10, len(users))
+	for _, n := range counts {
+		assert.True(n == 3 || n == 4)
+	}
+	_, err = b.Draw(make([]string, 13))
+	assert.Equal(ErrNotEnoughSeats, err)
+}
+
+func TestBalancerMove(t *testing.T) {
+	assert := assert.New(t)
+	hs := testTables(6, 6, 2)
+	b := NewBalancer(6, zap.NewNop())
+	b.Add(hs...)
+	moves, broken := b.Balance()
+	assert.Equal(0, len(broken))
+	assert.Equal(2, len(moves))
+	seats := make(map[int8]bool)
+	for _, m := range moves {
+		assert.Equal(hs[0], m.From)
+		assert.Equal(hs[1], m.To)
+		assert.False(seats[m.Seat])
+		seats[m.Seat] = true
+	}
+	//目标桌子预留了座位
+	assert.Equal(2, len(hs[1].State().EmptySeats))
+	//换桌还没有执行时不会重复移人
+	moves, _ = b.Balance()
+	assert.Equal(0, len(moves))
+}
+
+func TestBalancerBreakTable(t *testing.T) {
+	assert := assert.New(t)
+	hs := testTables(6, 2, 3, 2)
+	b := NewBalancer(6, zap.NewNop())
+	b.Add(hs...)
+	//淘汰的玩家不算人数
+	b.Eliminate("2-3")
+	moves, broken := b.Balance()
+	assert.Equal(1, len(b.Tables()))
+	assert.Equal(2, len(broken))
+	assert.Equal(4, len(moves))
+	left := b.Tables()[0]
+	for _, m := range moves {
+		assert.Equal(left, m.To)
+		assert.True(m.From != left)
+	}
+	assert.Equal(0, len(left.State().EmptySeats))
+}
+
+//testView 桌子的快照(seats为有人的座位,其他为空位)
+func testView(button, sb, bb int8, seats ...int8) *tableView {
+	v := &tableView{
+		state:    &holdem.HoldemState{HoldemBase: &holdem.HoldemBase{ButtonSeat: button, SBSeat: sb, BBSeat: bb}},
+		seats:    make(map[int8]string),
+		reserved: make(map[int8]bool),
+	}
+	for _, s := range seats {
+		v.seats[s] = fmt.Sprintf("u%d", s)
+	}
+	for s := int8(1); s <= 6; s++ {
+		if _, ok := v.seats[s]; !ok {
+			v.state.EmptySeats = append(v.state.EmptySeats, s)
+		}
+	}
+	v.count = len(v.seats)
+	return v
+}
+
+func TestBalancerSkipBlinds(t *testing.T) {
+	assert := assert.New(t)
+	b := NewBalancer(6, zap.NewNop())
+	b.skipped["u1"] = true
+	//空位4,5都在庄位和小盲之间
+	skip := testView(3, 6, 1, 1, 2, 3, 6)
+	//空位6在大盲后面
+	normal := testView(1, 2, 3, 1, 2, 3, 4, 5)
+	full := testView(1, 2, 3, 1, 2, 3, 4, 5, 6)
+	cases := []struct {
+		name  string
+		views []*tableView
+		id    string
+		dest  *tableView
+		full  bool
+	}{
+		{"fewest players", []*tableView{skip, normal}, "x", skip, false},
+		{"skipped", []*tableView{skip, normal}, "u1", normal, false},
+		//等下次平衡
+		{"skipped wait", []*tableView{skip, full}, "u1", nil, false},
+		{"full", []*tableView{full}, "x", nil, true},
+	}
+	for _, cs := range cases {
+		dest, f := b.destination(cs.views, cs.id)
+		assert.True(cs.dest == dest, cs.name)
+		assert.Equal(cs.full, f, cs.name)
+	}
+	seat, s := b.worstSeat(skip, false)
+	assert.Equal(int8(4), seat)
+	assert.True(s)
+	seat, _ = b.worstSeat(skip, true)
+	assert.Equal(int8(0), seat)
+	//下一手的大盲(2号)错过过盲注,移3号
+	from := testView(6, 1, 1, 1, 2, 3)
+	b.skipped["u2"] = true
+	assert.Equal("u3", b.candidate(from, skip))
+	assert.Equal("u2", b.candidate(from, normal))
+	//都错过过盲注时不移人
+	b.skipped["u3"] = true
+	assert.Equal("", b.candidate(from, skip))
+}
+
+func TestBalancerClearSkipped(t *testing.T) {
+	assert := assert.New(t)
+	b := NewBalancer(6, zap.NewNop())
+	var h *holdem.Holdem
+	var bb string
+	others := make([]string, 0)
+	done := make(chan bool)
+	h = holdem.NewHoldem("t1", 6, 10, time.Second, func(st *holdem.HoldemState) bool {
+		if st.HandNum == 0 {
+			return true
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, u := range st.Seated {
+			b.skipped[u.ID] = true
+			if u.SeatNumber == st.BBSeat {
+				bb = u.ID
+			} else {
+				others = append(others, u.ID)
+			}
+		}
+		b.snapshot([]*holdem.Holdem{h})
+		close(done)
+		return false
+	}, zap.NewNop())
+	for s := 1; s <= 3; s++ {
+		r := holdem.NewAgent(&holdem.NopReciever{}, fmt.Sprintf("p%d", s), zap.NewNop())
+		r.Join(h)
+		r.BringIn(1000)
+		r.Seated(int8(s))
+	}
+	//都不行动,超时弃牌/过牌
+	h.Start()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("hand not end")
+	}
+	//交过大盲的清除,其他的还在
+	assert.False(b.skipped[bb])
+	assert.Equal(2, len(others))
+	for _, id := range others {
+		assert.True(b.skipped[id])
+	}
+}
+
+func TestBetween(t *testing.T) {
+	assert := assert.New(t)
+	cases := []struct {
+		from, to, seat int8
+		ok             bool
+	}{
+		{1, 3, 2, true},
+		{1, 3, 3, false},
+		{5, 2, 6, true},
+		{5, 2, 1, true},
+		{5, 2, 3, false},
+		{2, 2, 1, true},
+	}
+	for _, cs := range cases {
+		assert.Equal(cs.ok, between(cs.from, cs.to, cs.seat, 6))
+	}
+}
+
+//testRecorder 记录比赛事件
+type testRecorder struct {
+	NopRecorder
+	mu     sync.Mutex
+	levels []int
+	broken []string
+	moved  []string
+	end    []*Finish
+}
+
+func (c *testRecorder) LevelChange(tid string, level int, l *holdem.BlindLevel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.levels = append(c.levels, level)
+}
+
+func (c *testRecorder) TableBroken(tid string, table string) {
+	c.broken = append(c.broken, table)
+}
+
+func (c *testRecorder) PlayerMoved(tid string, userID string, from string, to string) {
+	c.moved = append(c.moved, userID)
+}
+
+func (c *testRecorder) TournamentEnd(tid string, ledger []*Finish) {
+	c.end = ledger
+}
+
+func TestTournamentRegister(t *testing.T) {
+	assert := assert.New(t)
+	levels := holdem.BlindSchedule{{SmallBlind: 10}}
+	tm := NewTournament("mtt", 2, 1000, levels, time.Second, zap.NewNop())
+	_, err := tm.Register("a", &holdem.NopReciever{}, zap.NewNop())
+	assert.Nil(err)
+	_, err = tm.Register("a", &holdem.NopReciever{}, zap.NewNop())
+	assert.Equal(ErrPlayerRegistered, err)
+	assert.Equal(ErrNotEnoughPlayers, tm.Start())
+	_, err = tm.Register("b", &holdem.NopReciever{}, zap.NewNop())
+	assert.Nil(err)
+	assert.Equal(2, tm.Remaining())
+	cases := []struct {
+		name   string
+		levels holdem.BlindSchedule
+		err    error
+	}{
+		{"no levels", nil, ErrNoLevels},
+		{"by hands", holdem.BlindSchedule{{SmallBlind: 10, Hands: 10}}, ErrInvalidLevel},
+		{"first break", holdem.BlindSchedule{{Break: true, Duration: time.Minute}, {SmallBlind: 10}}, ErrInvalidLevel},
+		{"endless break", holdem.BlindSchedule{{SmallBlind: 10, Duration: time.Minute}, {Break: true}}, ErrInvalidLevel},
+	}
+	for _, cs := range cases {
+		tm.levels = cs.levels
+		assert.Equal(cs.err, tm.Start(), cs.name)
+	}
+}
+
+func TestTournamentLevels(t *testing.T) {
+	assert := assert.New(t)
+	rc := &testRecorder{}
+	levels := holdem.BlindSchedule{
+		{SmallBlind: 10, Duration: 30 * time.Millisecond},
+		{Break: true, Duration: 30 * time.Millisecond},
+		{SmallBlind: 20, BigBlind: 50, Ante: 5},
+	}
+	tm := NewTournament("mtt", 2, 1000, levels, time.Second, zap.NewNop(), OptionRecorder(rc))
+	for _, id := range []string{"a", "b"} {
+		_, err := tm.Register(id, &holdem.NopReciever{}, zap.NewNop())
+		assert.Nil(err)
+	}
+	assert.Nil(tm.Start())
+	_, err := tm.Register("c", &holdem.NopReciever{}, zap.NewNop())
+	assert.Equal(ErrTournamentStarted, err)
+	//休息时桌子暂停
+	time.Sleep(45 * time.Millisecond)
+	level, lv := tm.Level()
+	assert.Equal(1, level)
+	assert.True(lv.Break)
+	assert.True(tm.Tables()[0].State().Paused)
+	time.Sleep(45 * time.Millisecond)
+	level, lv = tm.Level()
+	assert.Equal(2, level)
+	assert.Equal(uint(50), lv.BigBlind)
+	assert.False(tm.Tables()[0].State().Paused)
+	rc.mu.Lock()
+	assert.Equal([]int{1, 2}, rc.levels)
+	rc.mu.Unlock()
+	for _, h := range tm.Tables() {
+		h.Stop()
+	}
+}
+
+func TestTournamentBreakTable(t *testing.T) {
+	assert := assert.New(t)
+	rc := &testRecorder{}
+	tm := NewTournament("mtt", 6, 1000, holdem.BlindSchedule{{SmallBlind: 10}}, time.Second, zap.NewNop(), OptionRecorder(rc))
+	hs := testTables(6, 4, 2)
+	for i, h := range hs {
+		tm.tables = append(tm.tables, &table{id: h.ID(), h: h})
+		tm.balancer.Add(h)
+		for s := 1; s <= []int{4, 2}[i]; s++ {
+			id := fmt.Sprintf("%d-%d", i+1, s)
+			tm.players[id] = &player{id: id, table: tm.tables[i], chip: 1000}
+		}
+	}
+	//6个人一张桌子就够了,拆掉人少的桌子
+	assert.True(tm.nextGame(tm.tables[0])(nil))
+	assert.False(tm.nextGame(tm.tables[1])(nil))
+	assert.True(tm.tables[1].broken)
+	assert.Equal([]string{"t2"}, rc.broken)
+	assert.Equal(2, len(rc.moved))
+	assert.Equal(1, len(tm.Tables()))
+	assert.Equal(0, len(hs[0].State().EmptySeats))
+}
+
+func TestTournamentFinishOrder(t *testing.T) {
+	assert := assert.New(t)
+	rc := &testRecorder{}
+	tm := NewTournament("mtt", 6, 1000, holdem.BlindSchedule{{SmallBlind: 10}}, time.Second, zap.NewNop(), OptionRecorder(rc))
+	tb := &table{id: "t1", h: testTables(6, 0)[0]}
+	tm.tables = []*table{tb}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tm.players[id] = &player{id: id, table: tb}
+	}
+	seated := func(chips ...uint) *holdem.HoldemState {
+		st := &holdem.HoldemState{HoldemBase: &holdem.HoldemBase{HandNum: 1}}
+		for i, chip := range chips {
+			st.Seated = append(st.Seated, &holdem.ShowUser{ID: string(rune('a' + i)), SeatNumber: int8(i + 1), Chip: chip})
+		}
+		return st
+	}
+	tm.handBegin(tb, seated(100, 300, 200, 400))
+	//a先被淘汰
+	tm.handEnd(tb, seated(0, 350, 250, 400))
+	assert.Equal(3, tm.Remaining())
+	assert.False(tm.Finished())
+	//同一手被淘汰的,开始时筹码多的名次靠前
+	tm.handBegin(tb, seated(0, 350, 250, 400))
+	tm.handEnd(tb, seated(0, 0, 0, 1000))
+	assert.True(tm.Finished())
+	ledger := tm.Ledger()
+	ids := make([]string, 0, len(ledger))
+	for i, f := range ledger {
+		assert.Equal(i+1, f.Position)
+		ids = append(ids, f.UserID)
+	}
+	assert.Equal([]string{"d", "b", "c", "a"}, ids)
+	assert.Equal(ledger, rc.end)
+}
@@ -0,0 +1,131 @@
+package holdem
+
+import "go.uber.org/zap"
+
+//transfer 等待执行的换桌
+type transfer struct {
+	r    *Agent
+	to   *Holdem
+	seat int8
+	chip uint
+}
+
+//Transfer 把玩家连同筹码和Agent换到另一张桌子的座位(0为自动找座),在本桌两手之间执行
+//先在目标桌子预留座位(被占时自动找座),预留不到座位不会离开本桌
+func (c *Holdem) Transfer(userID string, to *Holdem, seat int8) error {
+	seat, err := to.reserveSeat(userID, seat)
+	if err != nil {
+		return err
+	}
+	var old *transfer
+	c.seatLock.Lock()
+	for _, r := range c.players {
+		if r.id == userID {
+			old = r.gameInfo.transfer
+			r.gameInfo.transfer = &transfer{
+				to:   to,
+				seat: seat,
+			}
+			r.gameInfo.needStandUpReason = StandUpGameExchange
+			c.seatLock.Unlock()
+			//重新要求换桌的放掉之前预留的座位
+			if old != nil && (old.to != to || old.seat != seat) {
+				old.to.releaseSeat(userID, old.seat)
+			}
+			return nil
+		}
+	}
+	c.seatLock.Unlock()
+	to.releaseSeat(userID, seat)
+	return errPlayerNotAtTable
+}
+
+//reserveSeat 给换桌过来的玩家预留座位(被占时自动找座)
+func (c *Holdem) reserveSeat(userID string, seat int8) (int8, error) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	free := func(i int8) bool {
+		return c.players[i] == nil && (c.reserved[i] == "" || c.reserved[i] == userID)
+	}
+	if seat <= 0 || seat > c.seatCount || !free(seat) {
+		seat = 0
+		for i := int8(1); i <= c.seatCount; i++ {
+			if free(i) {
+				seat = i
+				break
+			}
+		}
+	}
+	if seat == 0 {
+		return 0, errTableIsFull
+	}
+	c.reserved[seat] = userID
+	return seat, nil
+}
+
+//releaseSeat 放掉预留的座位
+func (c *Holdem) releaseSeat(userID string, seat int8) {
+	c.seatLock.Lock()
+	defer c.seatLock.Unlock()
+	if c.reserved[seat] == userID {
+		delete(c.reserved, seat)
+	}
+}
+
+//queueTransfer 站起时如果要换桌,记录下来等解锁后执行(有锁)
+func (c *Holdem) queueTransfer(r *Agent) {
+	t := r.gameInfo.transfer
+	if t == nil {
+		return
+	}
+	t.r = r
+	t.chip = r.gameInfo.chip
+	c.transfers = append(c.transfers, t)
+}
+
+//sweepTransfers 两手之间执行等待中的换桌
+func (c *Holdem) sweepTransfers() {
+	c.seatLock.Lock()
+	for i, r := range c.players {
+		if r.gameInfo.transfer != nil {
+			c.log.Debug("user transfer stand up", zap.Int8("seat", i), zap.String("user", r.ID()))
+			c.standUp(i, r, StandUpGameExchange)
+		}
+	}
+	c.seatLock.Unlock()
+	c.doTransfers()
+}
+
+//doTransfers 离开本桌并坐到目标桌子预留的座位(无锁),没有筹码的只放掉预留的座位
+func (c *Holdem) doTransfers() {
+	c.seatLock.Lock()
+	ts := c.transfers
+	c.transfers = nil
+	c.seatLock.Unlock()
+	for _, t := range ts {
+		if t.chip == 0 {
+			t.to.releaseSeat(t.r.id, t.seat)
+			continue
+		}
+		c.leave(t.r)
+		t.to.transferIn(t)
+	}
+}
+
+//transferIn 换桌过来的玩家带着筹码直接坐到预留的座位
+func (c *Holdem) transferIn(t *transfer) {
+	r := t.r
+	c.join(r)
+	r.h = c
+	r.gameInfo = &gameInfo{
+		chip:    t.chip,
+		bringIn: t.chip,
+	}
+	c.seatLock.Lock()
+	delete(c.reserved, t.seat)
+	c.log.Debug("user transfer in", zap.Int8("seat", t.seat), zap.String("user", r.ID()), zap.Uint("chip", t.chip))
+	c.sitDown(t.seat, r)
+	info := c.information()
+	c.seatLock.Unlock()
+	c.autoStart(info)
+}